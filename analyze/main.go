@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"rabbitMQ/controllers"
+	"rabbitMQ/fuzzy"
+)
+
+func failOnError(err error, msg string) {
+	if err != nil {
+		log.Fatalf("%s: %s", msg, err)
+	}
+}
+
+func formatPoint(p map[string]float64) string {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s=%g", name, p[name])
+	}
+	return strings.Join(parts, " ")
+}
+
+func printReport(name string, r *fuzzy.Report) {
+	fmt.Printf("== %s\n", name)
+	for _, g := range r.Gaps {
+		fmt.Printf("GAP      %s .. %s (min firing strength %.4f)\n", formatPoint(g.From), formatPoint(g.To), g.MinStrength)
+	}
+	for _, c := range r.Conflicts {
+		fmt.Printf("CONFLICT rules %v share IF %s\n", c.Rules, c.If)
+	}
+	for _, o := range r.Overlaps {
+		fmt.Printf("OVERLAP  %s: %s and %s are %.0f%% similar\n", o.Var, o.Term1, o.Term2, 100*o.Similarity)
+	}
+	for _, d := range r.Duplicates {
+		fmt.Printf("DUPLICATE rules %v: IF %s\n", d.Rules, d.If)
+	}
+	for _, t := range r.UnusedTerms {
+		fmt.Printf("UNUSED   %s\n", t)
+	}
+	if !r.Failed() && !r.Warned() {
+		fmt.Println("OK")
+	}
+}
+
+// analyze sweeps the input space of the consumers' controllers and reports
+// gaps in the rule base, overlapping terms and conflicting rules. It exits
+// with status 1 when it finds gaps or conflicts, or with -strict any
+// warning, so it can gate CI. With -config it analyzes the controller in
+// that file instead, reported under the file name.
+func main() {
+	variantName := flag.String("variant", "", "built-in controller to analyze: gaussian, triangular or bell (default all; with -config, the file is the only controller)")
+	samples := flag.Int("samples", 401, "points each input universe is swept at")
+	minStrength := flag.Float64("min-strength", 0.5, "total firing strength below which a point is a gap")
+	maxOverlap := flag.Float64("max-overlap", 0.8, "term similarity above which two terms are redundant")
+	strict := flag.Bool("strict", false, "also fail on overlapping terms, duplicate rules and unused terms")
+	asJSON := flag.Bool("json", false, "print the reports as JSON")
+	controllerFlags := controllers.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	variants, err := controllerFlags.Variants(*variantName)
+	failOnError(err, "Invalid -variant")
+
+	opts := fuzzy.AnalysisOptions{Samples: *samples, MinStrength: *minStrength, MaxOverlap: *maxOverlap}
+	reports := map[string]*fuzzy.Report{}
+	failed := false
+	for _, v := range variants {
+		c, err := controllerFlags.Controller(v)
+		failOnError(err, "Failed to build the fuzzy controller")
+
+		r, err := fuzzy.Analyze(c, opts)
+		failOnError(err, "Failed to analyze "+v.Name)
+		reports[v.Name] = r
+		if r.Failed() || *strict && r.Warned() {
+			failed = true
+		}
+		if !*asJSON {
+			printReport(v.Name, r)
+		}
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		failOnError(enc.Encode(reports), "Failed to write the reports")
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,357 @@
+// Package anfis fits Takagi-Sugeno-Kang controllers to recorded samples with
+// the hybrid learning rule of ANFIS (Jang, 1993): each epoch solves the
+// linear rule consequents by least squares with the membership functions
+// held fixed, then moves the membership function parameters one gradient
+// step down the remaining error.
+package anfis
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"rabbitMQ/fuzzy"
+)
+
+// Sample is one recorded operating point: the crisp inputs of the controller,
+// including any input its Scales refer to, and the output it should have
+// produced there.
+type Sample struct {
+	In     map[string]float64
+	Target float64
+}
+
+// Options tune Train.
+type Options struct {
+	// Epochs is the number of least squares and gradient steps. Zero means
+	// 100.
+	Epochs int
+	// Rate is the initial gradient step of every membership function
+	// parameter, relative to the width of its universe. It is halved
+	// whenever a step would raise the error. Zero means 0.01.
+	Rate float64
+	// Ridge regularizes the least squares fit of the consequents, whose
+	// regressors are standardized first. Zero means 1e-6.
+	Ridge float64
+	// Regressors are the crisp inputs the linear consequents are fitted on.
+	// Nil fits them on the input variables of the controller.
+	Regressors []string
+	// Progress, when set, is called after every epoch with the root mean
+	// square error of the fit.
+	Progress func(epoch int, rmse float64)
+}
+
+// Result is the outcome of Train.
+type Result struct {
+	// Controller is the controller of the epoch with the lowest error.
+	Controller *fuzzy.Controller
+	// RMSE is the root mean square error after the least squares step of
+	// every epoch, and Best the epoch of Controller.
+	RMSE []float64
+	Best int
+}
+
+// param is one trainable membership function parameter: Params[index] of
+// term of input. scale is the size of a unit step of the parameter.
+type param struct {
+	input, term, index int
+	scale              float64
+}
+
+// trainable lists, by membership function type, which parameters are
+// coordinates of the universe; the remaining ones are shape parameters
+// with unit scale. Other types are left as they are.
+var trainable = map[string][]bool{
+	"triangular":  {true, true, true},
+	"trapezoidal": {true, true, true, true},
+	"gaussian":    {true, true},
+	"bell":        {true, false, true},
+}
+
+type trainer struct {
+	d          *fuzzy.Definition
+	samples    []Sample
+	scaled     []map[string]float64 // samples[i].In after the controller Scales
+	regressors []string
+	mean, std  []float64 // of every regressor over the scaled samples
+	ridge      float64
+	gain       float64
+}
+
+// Train fits c to samples: every rule gets a linear consequent of
+// opts.Regressors, solved by least squares, and the parameters of its
+// triangular, trapezoidal, gaussian and bell input terms are tuned by
+// gradient descent. The defuzzifier becomes the weighted average, which
+// TSK consequents need; rule weights, scales and the output gain are kept,
+// while rule gains are dropped, as the consequents are fitted anew.
+// c itself is not modified.
+func Train(c *fuzzy.Controller, samples []Sample, opts Options) (*Result, error) {
+	if len(samples) == 0 {
+		return nil, errors.New("anfis: no samples")
+	}
+	if opts.Epochs == 0 {
+		opts.Epochs = 100
+	}
+	if opts.Rate == 0 {
+		opts.Rate = 0.01
+	}
+	if opts.Ridge == 0 {
+		opts.Ridge = 1e-6
+	}
+	if opts.Regressors == nil {
+		for _, v := range c.Inputs {
+			opts.Regressors = append(opts.Regressors, v.Name)
+		}
+	}
+
+	d, err := fuzzy.NewDefinition(c)
+	if err != nil {
+		return nil, err
+	}
+	d.Defuzzifier = fuzzy.WeightedAverage{}.String()
+	for i := range d.Rules {
+		d.Rules[i].Then, d.Rules[i].TSK, d.Rules[i].Gain = "", &fuzzy.Linear{}, nil
+	}
+	// Regressors that are not input variables are declared crisp inputs.
+	known := map[string]bool{}
+	for _, v := range d.Inputs {
+		known[v.Name] = true
+	}
+	for _, name := range d.Crisp {
+		known[name] = true
+	}
+	for _, name := range opts.Regressors {
+		if !known[name] {
+			d.Crisp = append(d.Crisp, name)
+			known[name] = true
+		}
+	}
+
+	t := &trainer{d: d, samples: samples, regressors: opts.Regressors, ridge: opts.Ridge, gain: c.OutputGain}
+	if t.gain == 0 {
+		t.gain = 1
+	}
+	if err := t.standardize(c); err != nil {
+		return nil, err
+	}
+	params := t.params()
+
+	// Adam moments of every parameter.
+	m, v := make([]float64, len(params)), make([]float64, len(params))
+	const beta1, beta2, eps = 0.9, 0.999, 1e-8
+	rate := opts.Rate
+
+	res := &Result{Best: -1}
+	for epoch := 0; epoch < opts.Epochs; epoch++ {
+		if err := t.leastSquares(); err != nil {
+			return nil, err
+		}
+		ctrl, err := t.d.Controller()
+		if err != nil {
+			return nil, err
+		}
+		loss := t.loss(ctrl)
+		res.RMSE = append(res.RMSE, loss)
+		if res.Best < 0 || loss < res.RMSE[res.Best] {
+			res.Controller, res.Best = ctrl, epoch
+		}
+		if opts.Progress != nil {
+			opts.Progress(epoch, loss)
+		}
+		if epoch == opts.Epochs-1 || len(params) == 0 {
+			continue
+		}
+
+		grad := t.gradient(params, loss)
+		old := make([]float64, len(params))
+		for i, p := range params {
+			old[i] = t.get(p)
+			m[i] = beta1*m[i] + (1-beta1)*grad[i]
+			v[i] = beta2*v[i] + (1-beta2)*grad[i]*grad[i]
+			mHat := m[i] / (1 - math.Pow(beta1, float64(epoch+1)))
+			vHat := v[i] / (1 - math.Pow(beta2, float64(epoch+1)))
+			t.set(p, old[i]-rate*p.scale*mHat/(math.Sqrt(vHat)+eps))
+		}
+		if t.lossOf() > loss {
+			for i, p := range params {
+				t.set(p, old[i])
+			}
+			rate /= 2
+		}
+	}
+	return res, nil
+}
+
+// standardize records the scaled inputs of every sample and the mean and
+// standard deviation of every regressor over them.
+func (t *trainer) standardize(c *fuzzy.Controller) error {
+	t.scaled = make([]map[string]float64, len(t.samples))
+	for i, s := range t.samples {
+		in, err := c.ScaleInputs(s.In)
+		if err != nil {
+			return fmt.Errorf("anfis: sample %d: %w", i+1, err)
+		}
+		for _, name := range t.regressors {
+			if _, ok := in[name]; !ok {
+				return fmt.Errorf("anfis: sample %d: missing regressor %q", i+1, name)
+			}
+		}
+		t.scaled[i] = in
+	}
+
+	n := float64(len(t.samples))
+	t.mean, t.std = make([]float64, len(t.regressors)), make([]float64, len(t.regressors))
+	for k, name := range t.regressors {
+		for _, in := range t.scaled {
+			t.mean[k] += in[name] / n
+		}
+		for _, in := range t.scaled {
+			t.std[k] += (in[name] - t.mean[k]) * (in[name] - t.mean[k]) / n
+		}
+		t.std[k] = math.Sqrt(t.std[k])
+	}
+	return nil
+}
+
+// params lists the trainable membership function parameters of t.d.
+func (t *trainer) params() []param {
+	var ps []param
+	for i, vd := range t.d.Inputs {
+		width := vd.Range[1] - vd.Range[0]
+		for j, td := range vd.Terms {
+			coords, ok := trainable[td.Type]
+			if !ok {
+				continue
+			}
+			for k, coord := range coords {
+				if math.IsInf(td.Params[k], 0) {
+					continue
+				}
+				scale := 1.0
+				if coord {
+					scale = width
+				}
+				ps = append(ps, param{input: i, term: j, index: k, scale: scale})
+			}
+		}
+	}
+	return ps
+}
+
+func (t *trainer) get(p param) float64 {
+	return t.d.Inputs[p.input].Terms[p.term].Params[p.index]
+}
+
+func (t *trainer) set(p param, x float64) {
+	t.d.Inputs[p.input].Terms[p.term].Params[p.index] = x
+}
+
+// leastSquares solves the consequents of every rule for the current
+// membership functions. The output is linear in them: with normalized
+// firing strengths s_i and weights w_i it is the sum over the rules of
+// s_i w_i (c_i + Σ a_ik x_k).
+func (t *trainer) leastSquares() error {
+	ctrl, err := t.d.Controller()
+	if err != nil {
+		return err
+	}
+	nr, nx := len(t.d.Rules), len(t.regressors)+1
+	var a [][]float64
+	var b []float64
+	for i, s := range t.samples {
+		out, err := ctrl.Fire(s.In)
+		if err != nil {
+			return fmt.Errorf("anfis: sample %d: %w", i+1, err)
+		}
+		ws := make([]float64, len(out.Activations))
+		total := 0.0
+		for r, act := range out.Activations {
+			ws[r] = act.Strength
+			if act.Rule.Weight != 0 {
+				ws[r] *= act.Rule.Weight
+			}
+			total += ws[r]
+		}
+		if total == 0 {
+			continue // no rule fires: the consequents play no part
+		}
+		row := make([]float64, nr*nx)
+		for r := range out.Activations {
+			s := ws[r] / total
+			row[r*nx] = s
+			for k, name := range t.regressors {
+				if t.std[k] > 0 {
+					row[r*nx+1+k] = s * (t.scaled[i][name] - t.mean[k]) / t.std[k]
+				}
+			}
+		}
+		a = append(a, row)
+		b = append(b, s.Target/t.gain)
+	}
+
+	x, err := leastSquares(a, b, t.ridge)
+	if err != nil {
+		return err
+	}
+	// Undo the standardization of the regressors.
+	for r := range t.d.Rules {
+		l := &fuzzy.Linear{Const: x[r*nx]}
+		for k, name := range t.regressors {
+			if t.std[k] == 0 {
+				continue
+			}
+			coeff := x[r*nx+1+k] / t.std[k]
+			if l.Coeffs == nil {
+				l.Coeffs = map[string]float64{}
+			}
+			l.Coeffs[name] = coeff
+			l.Const -= coeff * t.mean[k]
+		}
+		t.d.Rules[r].TSK = l
+	}
+	return nil
+}
+
+// loss returns the root mean square error of c over the samples.
+func (t *trainer) loss(c *fuzzy.Controller) float64 {
+	sum := 0.0
+	for _, s := range t.samples {
+		u, err := c.Evaluate(s.In)
+		if err != nil {
+			return math.Inf(1)
+		}
+		sum += (u - s.Target) * (u - s.Target)
+	}
+	return math.Sqrt(sum / float64(len(t.samples)))
+}
+
+// lossOf returns the loss of the controller t.d describes, or +Inf when its
+// parameters are not valid.
+func (t *trainer) lossOf() float64 {
+	c, err := t.d.Controller()
+	if err != nil {
+		return math.Inf(1)
+	}
+	return t.loss(c)
+}
+
+// gradient returns the derivative of the loss, at loss, along every param
+// in units of its scale, by finite differences. A parameter that cannot
+// move either way, e.g. the shared corner of a shoulder, gets zero.
+func (t *trainer) gradient(params []param, loss float64) []float64 {
+	const h = 1e-4
+	grad := make([]float64, len(params))
+	for i, p := range params {
+		x := t.get(p)
+		for _, dir := range []float64{1, -1} {
+			t.set(p, x+dir*h*p.scale)
+			l := t.lossOf()
+			if !math.IsInf(l, 0) {
+				grad[i] = dir * (l - loss) / h
+				break
+			}
+		}
+		t.set(p, x)
+	}
+	return grad
+}
@@ -0,0 +1,122 @@
+package anfis
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"rabbitMQ/fuzzy"
+)
+
+// testController has one input x over [0, 10] split by two complementary
+// triangles, each concluding on its own output term.
+func testController() *fuzzy.Controller {
+	return &fuzzy.Controller{
+		Name: "test",
+		Inputs: []*fuzzy.Variable{{Name: "x", Min: 0, Max: 10, Terms: []fuzzy.Term{
+			{Name: "LOW", MF: fuzzy.Triangular{A: -10, B: 0, C: 10}},
+			{Name: "HIGH", MF: fuzzy.Triangular{A: 0, B: 10, C: 20}},
+		}}},
+		Output: &fuzzy.Variable{Name: "y", Min: -100, Max: 100, Terms: []fuzzy.Term{
+			{Name: "A", MF: fuzzy.Triangular{A: -1, B: 0, C: 1}},
+			{Name: "B", MF: fuzzy.Triangular{A: 0, B: 1, C: 2}},
+		}},
+		Rules: []fuzzy.Rule{
+			{If: fuzzy.Is{Var: "x", Term: "LOW"}, Then: "A", Weight: 0.5, Gain: 2},
+			{If: fuzzy.Is{Var: "x", Term: "HIGH"}, Then: "B"},
+		},
+	}
+}
+
+func TestLeastSquares(t *testing.T) {
+	// x + y = 3, x - y = 1, 2x = 4 is consistent: x = 2, y = 1.
+	x, err := leastSquares([][]float64{{1, 1}, {1, -1}, {2, 0}}, []float64{3, 1, 4}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(x[0]-2) > 1e-12 || math.Abs(x[1]-1) > 1e-12 {
+		t.Errorf("leastSquares = %v, want [2 1]", x)
+	}
+
+	// y = 0, y = 2 has the least squares solution y = 1.
+	x, err = leastSquares([][]float64{{1}, {1}}, []float64{0, 2}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(x[0]-1) > 1e-12 {
+		t.Errorf("leastSquares = %v, want [1]", x)
+	}
+
+	if _, err := leastSquares(nil, nil, 0); err == nil {
+		t.Error("leastSquares accepted no rows")
+	}
+}
+
+// TestTrainFitsLinearTarget checks that a target linear in the regressors is
+// fitted exactly, also between the samples, by the first least squares
+// step, whatever the rule weights, and that regressors which are not input
+// variables become crisp inputs of the trained controller.
+func TestTrainFitsLinearTarget(t *testing.T) {
+	var samples []Sample
+	for i := 0; i <= 20; i++ {
+		x, p := float64(i)/2, float64(i%7)
+		samples = append(samples, Sample{In: map[string]float64{"x": x, "p": p}, Target: 1 + 2*x - 0.5*p})
+	}
+	res, err := Train(testController(), samples, Options{Epochs: 1, Regressors: []string{"x", "p"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RMSE[0] > 1e-5 {
+		t.Errorf("RMSE = %g, want 0", res.RMSE[0])
+	}
+
+	c := res.Controller
+	if len(c.Crisp) != 1 || c.Crisp[0] != "p" {
+		t.Errorf("crisp inputs = %v, want [p]", c.Crisp)
+	}
+	if c.Rules[0].Weight != 0.5 || c.Rules[0].Gain != 0 {
+		t.Errorf("rule 1 has weight %g and gain %g, want 0.5 and none", c.Rules[0].Weight, c.Rules[0].Gain)
+	}
+	for _, in := range []map[string]float64{{"x": 3.3, "p": 2.5}, {"x": 8.9, "p": -1}} {
+		u, err := c.Evaluate(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := 1 + 2*in["x"] - 0.5*in["p"]; math.Abs(u-want) > 1e-4 {
+			t.Errorf("output at %v = %g, want %g", in, u, want)
+		}
+	}
+}
+
+// TestTrainMovesTerms checks that the gradient steps lower the error the
+// least squares step leaves when the consequents alone cannot fit the
+// target: with constant consequents the output blends two constants along
+// the fixed terms, while the target bends at x = 7.
+func TestTrainMovesTerms(t *testing.T) {
+	var samples []Sample
+	for i := 0; i <= 40; i++ {
+		x := float64(i) / 4
+		samples = append(samples, Sample{In: map[string]float64{"x": x}, Target: math.Max(0, x-7)})
+	}
+	res, err := Train(testController(), samples, Options{Epochs: 50, Rate: 0.05, Regressors: []string{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Best == 0 || res.RMSE[res.Best] > 0.8*res.RMSE[0] {
+		t.Errorf("best RMSE %g at epoch %d, want well below the first %g", res.RMSE[res.Best], res.Best, res.RMSE[0])
+	}
+	if res.Controller.Inputs[0].Terms[0].MF == testController().Inputs[0].Terms[0].MF {
+		t.Error("LOW did not move")
+	}
+}
+
+func TestTrainErrors(t *testing.T) {
+	if _, err := Train(testController(), nil, Options{}); err == nil || !strings.Contains(err.Error(), "no samples") {
+		t.Errorf("Train with no samples = %v", err)
+	}
+	samples := []Sample{{In: map[string]float64{"x": 1}, Target: 1}}
+	_, err := Train(testController(), samples, Options{Regressors: []string{"x", "p"}})
+	if err == nil || !strings.Contains(err.Error(), `sample 1: missing regressor "p"`) {
+		t.Errorf("Train with a missing regressor = %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package anfis
+
+import (
+	"errors"
+	"math"
+)
+
+// leastSquares returns the x minimizing |A x - b|² + ridge |x|², by solving
+// the normal equations (AᵀA + ridge I) x = Aᵀb.
+func leastSquares(a [][]float64, b []float64, ridge float64) ([]float64, error) {
+	if len(a) == 0 {
+		return nil, errors.New("anfis: no samples")
+	}
+	n := len(a[0])
+	m := make([][]float64, n)
+	for i := range m {
+		m[i] = make([]float64, n+1)
+	}
+	for r, row := range a {
+		for i := 0; i < n; i++ {
+			if row[i] == 0 {
+				continue
+			}
+			for j := i; j < n; j++ {
+				m[i][j] += row[i] * row[j]
+			}
+			m[i][n] += row[i] * b[r]
+		}
+	}
+	for i := 0; i < n; i++ {
+		m[i][i] += ridge
+		for j := 0; j < i; j++ {
+			m[i][j] = m[j][i]
+		}
+	}
+	return solve(m)
+}
+
+// solve solves the n×n system held in the augmented n×(n+1) matrix m by
+// Gaussian elimination with partial pivoting. m is overwritten.
+func solve(m [][]float64) ([]float64, error) {
+	n := len(m)
+	for col := 0; col < n; col++ {
+		pivot := col
+		for r := col + 1; r < n; r++ {
+			if math.Abs(m[r][col]) > math.Abs(m[pivot][col]) {
+				pivot = r
+			}
+		}
+		if math.Abs(m[pivot][col]) < 1e-300 {
+			return nil, errors.New("anfis: singular least squares system, try a larger ridge")
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+		for r := col + 1; r < n; r++ {
+			f := m[r][col] / m[col][col]
+			if f == 0 {
+				continue
+			}
+			for c := col; c <= n; c++ {
+				m[r][c] -= f * m[col][c]
+			}
+		}
+	}
+
+	x := make([]float64, n)
+	for r := n - 1; r >= 0; r-- {
+		s := m[r][n]
+		for c := r + 1; c < n; c++ {
+			s -= m[r][c] * x[c]
+		}
+		x[r] = s / m[r][r]
+	}
+	return x, nil
+}
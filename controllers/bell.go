@@ -0,0 +1,44 @@
+package controllers
+
+import "rabbitMQ/fuzzy"
+
+// Bell returns the controller with generalized bell membership functions.
+// Every error term shares the same width and slope, and the rules are not
+// weighted.
+func Bell() *fuzzy.Controller {
+	a, b := 650.0, 0.87
+	outA, outB := 2.0, 2.0
+
+	return &fuzzy.Controller{
+		Name: "bell",
+		Inputs: []*fuzzy.Variable{{
+			Name: Error,
+			Min:  -20000,
+			Max:  20000,
+			Terms: []fuzzy.Term{
+				{Name: LargeNegative, MF: fuzzy.Bell{A: a, B: b, C: -10000}},
+				{Name: MediumNegative, MF: fuzzy.Bell{A: a, B: b, C: -2500}},
+				{Name: SmallNegative, MF: fuzzy.Bell{A: a, B: b, C: -500}},
+				{Name: Zero, MF: fuzzy.Bell{A: a, B: b, C: 0}},
+				{Name: SmallPositive, MF: fuzzy.Bell{A: a, B: b, C: 500}},
+				{Name: MediumPositive, MF: fuzzy.Bell{A: a, B: b, C: 2500}},
+				{Name: LargePositive, MF: fuzzy.Bell{A: a, B: b, C: 10000}},
+			},
+		}},
+		Output: &fuzzy.Variable{
+			Name: Output,
+			Min:  -4,
+			Max:  4,
+			Terms: []fuzzy.Term{
+				{Name: LargeDecrease, MF: fuzzy.Bell{A: outA, B: outB, C: -4.0}},
+				{Name: SmallDecrease, MF: fuzzy.Bell{A: outA, B: outB, C: -2.0}},
+				{Name: Maintain, MF: fuzzy.Bell{A: 0.5, B: outB, C: 0.0}}, // Narrower for Maintain
+				{Name: SmallIncrease, MF: fuzzy.Bell{A: outA, B: outB, C: 2.0}},
+				{Name: LargeIncrease, MF: fuzzy.Bell{A: outA, B: outB, C: 4.0}},
+			},
+		},
+		Rules:   rules(nil),
+		NoFire:  fuzzy.NoFireDefault,
+		Default: 0,
+	}
+}
@@ -0,0 +1,54 @@
+// Package controllers holds the fuzzy prefetch controllers used by the
+// gaussian, triangular and bell consumers.
+package controllers
+
+import "rabbitMQ/fuzzy"
+
+// Variable names.
+const (
+	Error  = "error"  // goal - rate, in msg/sec
+	Output = "output" // prefetch adjustment
+)
+
+// Error terms.
+const (
+	LargeNegative  = "LN"
+	MediumNegative = "MN"
+	SmallNegative  = "SN"
+	Zero           = "ZE"
+	SmallPositive  = "SP"
+	MediumPositive = "MP"
+	LargePositive  = "LP"
+)
+
+// Output terms.
+const (
+	LargeIncrease = "LI"
+	SmallIncrease = "SI"
+	Maintain      = "MAINTAIN"
+	SmallDecrease = "SD"
+	LargeDecrease = "LD"
+)
+
+// importanceFactors are the consequent gains of the gaussian and triangular
+// controllers, in rule order: they scale the output of every rule, not its
+// firing strength.
+var importanceFactors = []float64{1.5, 1.5, 1.0, 1.0, 0.5, 0.5, 0.3}
+
+// rules returns the rule base shared by every controller. gains may be nil,
+// in which case every rule has gain 1.
+func rules(gains []float64) []fuzzy.Rule {
+	rs := []fuzzy.Rule{
+		{If: fuzzy.Is{Var: Error, Term: LargePositive}, Then: LargeIncrease},
+		{If: fuzzy.Is{Var: Error, Term: MediumPositive}, Then: LargeIncrease},
+		{If: fuzzy.Is{Var: Error, Term: SmallPositive}, Then: SmallIncrease},
+		{If: fuzzy.Is{Var: Error, Term: Zero}, Then: Maintain},
+		{If: fuzzy.Is{Var: Error, Term: SmallNegative}, Then: SmallDecrease},
+		{If: fuzzy.Is{Var: Error, Term: MediumNegative}, Then: LargeDecrease},
+		{If: fuzzy.Is{Var: Error, Term: LargeNegative}, Then: LargeDecrease},
+	}
+	for i := range gains {
+		rs[i].Gain = gains[i]
+	}
+	return rs
+}
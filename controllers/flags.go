@@ -0,0 +1,199 @@
+package controllers
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"rabbitMQ/fuzzy"
+)
+
+// Variant is one of the consumers' controllers.
+type Variant struct {
+	Name  string
+	New   func() *fuzzy.Controller
+	Delta func() []fuzzy.Term // change of error terms of the same shape
+	Goal  float64             // setpoint of the consumer, in msg/sec
+}
+
+// Variants lists the controllers of the gaussian, triangular and bell
+// consumers.
+var Variants = []Variant{
+	{Name: "gaussian", New: Gaussian, Delta: GaussianDelta, Goal: 25000},
+	{Name: "triangular", New: Triangular, Delta: TriangularDelta, Goal: 10000},
+	{Name: "bell", New: Bell, Delta: BellDelta, Goal: 30000},
+}
+
+// Lookup returns the variant called name.
+func Lookup(name string) (Variant, bool) {
+	for _, v := range Variants {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return Variant{}, false
+}
+
+// Flags are the command line options that pick and tune the controller of a
+// consumer.
+type Flags struct {
+	fs *flag.FlagSet
+
+	config      string
+	defuzzifier string
+	operators   fuzzy.Operators
+	implication fuzzy.Implication
+	aggregation fuzzy.Aggregation
+	resolution  int
+	peakRes     int
+	noFire      fuzzy.NoFirePolicy
+	noFireValue float64
+	tsk, pd     bool
+	shoulders   bool
+	shape       fuzzy.Shape
+	spacing     fuzzy.Spacing
+	overlap     float64
+	scale       fuzzy.ScaleMode
+	scaleGoal   float64
+	outputGain  float64
+	type2       float64
+}
+
+// RegisterFlags defines the controller flags on fs.
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{fs: fs}
+	fs.StringVar(&f.config, "config", "", "load the controller from this FCL, JSON or YAML file instead of the built-in one")
+	fs.StringVar(&f.defuzzifier, "defuzzifier", "weighted-average", "weighted-average, centroid, bisector, mom, som or lom")
+	fs.Var(&f.operators, "operators", "AND/OR operators of compound rules: minmax, product, lukasiewicz, hamacher, einstein or drastic")
+	fs.Var(&f.implication, "implication", "implication of the output set: min (clip) or prod (scale)")
+	fs.Var(&f.aggregation, "aggregation", "aggregation of the output set: max or sum")
+	fs.IntVar(&f.resolution, "resolution", fuzzy.DefaultResolution, "output universe samples of the output set")
+	fs.IntVar(&f.peakRes, "peak-resolution", 0, "output universe intervals searched for the peak of each output term (0 = exact peak)")
+	fs.Var(&f.noFire, "no-fire", "output when no rule fires: default, hold or error")
+	fs.Float64Var(&f.noFireValue, "no-fire-value", 0, "output under the default no-fire policy")
+	fs.Var(&f.shape, "partition", "regenerate the error terms as a partition of this shape: triangular, gaussian or bell (default the shape of the current ones)")
+	fs.Var(&f.spacing, "spacing", "peak spacing of the -partition error terms: uniform or log")
+	fs.Float64Var(&f.overlap, "overlap", 0.5, "membership at which neighbouring -partition terms cross")
+	fs.BoolVar(&f.shoulders, "shoulders", false, "hold the outermost error terms at full membership beyond their peaks (triangular and trapezoidal terms)")
+	fs.BoolVar(&f.pd, "pd", false, "add the change of error input and its two input rule table")
+	fs.Var(&f.scale, "scale", "take the error relative to the goal: percent or unit ([-1, 1])")
+	fs.Float64Var(&f.scaleGoal, "scale-goal", 0, "goal the error terms were tuned at, for -scale (default the variant's goal)")
+	fs.Float64Var(&f.outputGain, "output-gain", 1, "factor the crisp output is multiplied by")
+	fs.BoolVar(&f.tsk, "tsk", false, "use Takagi-Sugeno-Kang consequents instead of output terms")
+	fs.Float64Var(&f.type2, "type2", 0, "make the input terms interval type-2, their widths uncertain by this fraction either way (0 = type-1)")
+	return f
+}
+
+// Variants returns the variant called name, or all of them when name is
+// empty. With -config there is a single controller, the one in the file, so
+// Variants returns one variant named after the file; it keeps the goal and
+// change of error terms of the variant called name, or of the first one,
+// for -scale and -pd.
+func (f *Flags) Variants(name string) ([]Variant, error) {
+	vs := Variants
+	if name != "" {
+		v, ok := Lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("controllers: unknown variant %q", name)
+		}
+		vs = []Variant{v}
+	}
+	if f.config == "" {
+		return vs, nil
+	}
+	v := vs[0]
+	v.Name = strings.TrimSuffix(filepath.Base(f.config), filepath.Ext(f.config))
+	return []Variant{v}, nil
+}
+
+// Controller returns the controller the flags select: the one in -config
+// when given, otherwise the built-in one of v. Flags left unset keep the
+// controller's own settings. The result is validated, so conflicting
+// flags, such as -type2 with -defuzzifier centroid, are reported here.
+func (f *Flags) Controller(v Variant) (*fuzzy.Controller, error) {
+	c := v.New()
+	if f.config != "" {
+		var err error
+		if c, err = fuzzy.LoadFile(f.config); err != nil {
+			return nil, err
+		}
+	}
+
+	set := map[string]bool{}
+	f.fs.Visit(func(fl *flag.Flag) { set[fl.Name] = true })
+
+	if set["defuzzifier"] {
+		d, err := fuzzy.ParseDefuzzifier(f.defuzzifier)
+		if err != nil {
+			return nil, err
+		}
+		c.Defuzzifier = d
+	}
+	if set["operators"] {
+		c.Operators = f.operators
+	}
+	if set["implication"] {
+		c.Implication = f.implication
+	}
+	if set["aggregation"] {
+		c.Aggregation = f.aggregation
+	}
+	if set["resolution"] {
+		c.Resolution = f.resolution
+	}
+	if set["peak-resolution"] {
+		c.PeakResolution = f.peakRes
+	}
+	if set["no-fire"] {
+		c.NoFire = f.noFire
+	}
+	if set["no-fire-value"] {
+		c.Default = f.noFireValue
+	}
+	if set["partition"] || set["spacing"] || set["overlap"] {
+		opts := fuzzy.PartitionOptions{Shape: f.shape, Spacing: f.spacing, Overlap: f.overlap}
+		if !set["partition"] {
+			shape, err := ErrorShape(c)
+			if err != nil {
+				return nil, err
+			}
+			opts.Shape = shape
+		}
+		if err := Repartition(c, opts); err != nil {
+			return nil, err
+		}
+	}
+	if f.shoulders {
+		if err := Shoulders(c); err != nil {
+			return nil, err
+		}
+	}
+	if f.pd {
+		AddChangeOfError(c, v.Delta())
+	}
+	if f.tsk {
+		ToTSK(c)
+	}
+	if f.type2 != 0 {
+		if err := ToType2(c, f.type2); err != nil {
+			return nil, err
+		}
+	}
+	if set["scale"] {
+		nominal := v.Goal
+		if set["scale-goal"] {
+			nominal = f.scaleGoal
+		}
+		if err := Normalize(c, f.scale, nominal); err != nil {
+			return nil, err
+		}
+	}
+	if set["output-gain"] {
+		c.OutputGain = f.outputGain
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,53 @@
+package controllers_test
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"rabbitMQ/controllers"
+	"rabbitMQ/fuzzy"
+)
+
+// TestFlagsConfigPartition checks that -spacing and -overlap without
+// -partition regenerate the error terms of a -config controller in the
+// shape they already have, although the variant is named after the file.
+func TestFlagsConfigPartition(t *testing.T) {
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		file string
+		c    *fuzzy.Controller
+		want fuzzy.MembershipFunction
+	}{
+		{"tri.fcl", controllers.Triangular(), fuzzy.Triangular{}},
+		{"gauss.json", controllers.Gaussian(), fuzzy.Gaussian{}},
+		{"bell.yaml", controllers.Bell(), fuzzy.Bell{}},
+	} {
+		path := filepath.Join(dir, tc.file)
+		if err := fuzzy.SaveFile(path, tc.c); err != nil {
+			t.Fatal(err)
+		}
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		f := controllers.RegisterFlags(fs)
+		if err := fs.Parse([]string{"-config", path, "-spacing", "log"}); err != nil {
+			t.Fatal(err)
+		}
+		vs, err := f.Variants("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(vs) != 1 || vs[0].Name != tc.file[:len(tc.file)-len(filepath.Ext(tc.file))] {
+			t.Fatalf("%s: variants %v, want one named after the file", tc.file, vs)
+		}
+		c, err := f.Controller(vs[0])
+		if err != nil {
+			t.Fatalf("%s: %v", tc.file, err)
+		}
+		for _, term := range c.Inputs[0].Terms {
+			if got, want := fmt.Sprintf("%T", term.MF), fmt.Sprintf("%T", tc.want); got != want {
+				t.Errorf("%s: term %s is a %s, want a %s", tc.file, term.Name, got, want)
+			}
+		}
+	}
+}
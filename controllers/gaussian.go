@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"math"
+
+	"rabbitMQ/fuzzy"
+)
+
+func calculateSigma(currentPeak, nextPeak float64) float64 {
+	const factor = 1 //1.1774100225154747 // Pre-calculated value for efficiency
+	midpoint := (currentPeak + nextPeak) / 2
+	return math.Abs(currentPeak-midpoint) / factor
+}
+
+func sigmas(peaks []float64) []float64 {
+	s := make([]float64, len(peaks)-1)
+	for i := 0; i < len(peaks)-1; i++ {
+		s[i] = calculateSigma(peaks[i], peaks[i+1])
+	}
+	return s
+}
+
+// Gaussian returns the controller with gaussian membership functions.
+func Gaussian() *fuzzy.Controller {
+	in := sigmas([]float64{
+		-20000, // Large Negative
+		-10000, // Start of next category after Large Negative
+		-2500,  // Medium Negative
+		-500,   // Small Negative
+		0,      // Zero
+		500,    // Small Positive
+		2500,   // Medium Positive
+		10000,  // Large Positive
+		20000,  // End of the range after Large Positive
+	})
+
+	out := sigmas([]float64{
+		-4, // Large Negative
+		-3, // Start of next category after Large Negative
+		-2, // Medium Negative
+		-1, // Small Negative
+		0,  // Zero
+		1,  // Small Positive
+		2,  // Medium Positive
+		3,  // Large Positive
+		4,  // End of the range after Large Positive
+	})
+
+	return &fuzzy.Controller{
+		Name: "gaussian",
+		Inputs: []*fuzzy.Variable{{
+			Name: Error,
+			Min:  -20000,
+			Max:  20000,
+			Terms: []fuzzy.Term{
+				{Name: LargeNegative, MF: fuzzy.Gaussian{Mu: -10000, Sigma: in[0]}},
+				{Name: MediumNegative, MF: fuzzy.Gaussian{Mu: -2500, Sigma: in[1]}},
+				{Name: SmallNegative, MF: fuzzy.Gaussian{Mu: -500, Sigma: in[2]}},
+				{Name: Zero, MF: fuzzy.Gaussian{Mu: 0, Sigma: in[3]}},
+				{Name: SmallPositive, MF: fuzzy.Gaussian{Mu: 500, Sigma: in[4]}},
+				{Name: MediumPositive, MF: fuzzy.Gaussian{Mu: 2500, Sigma: in[5]}},
+				{Name: LargePositive, MF: fuzzy.Gaussian{Mu: 10000, Sigma: in[6]}},
+			},
+		}},
+		Output: &fuzzy.Variable{
+			Name: Output,
+			Min:  -4,
+			Max:  4,
+			Terms: []fuzzy.Term{
+				{Name: LargeIncrease, MF: fuzzy.Gaussian{Mu: 3.0, Sigma: out[7]}},
+				{Name: SmallIncrease, MF: fuzzy.Gaussian{Mu: 1.5, Sigma: out[5]}},
+				{Name: Maintain, MF: fuzzy.Gaussian{Mu: 0.0, Sigma: out[4]}},
+				{Name: SmallDecrease, MF: fuzzy.Gaussian{Mu: -1.5, Sigma: out[2]}},
+				{Name: LargeDecrease, MF: fuzzy.Gaussian{Mu: -2.0, Sigma: out[0]}},
+			},
+		},
+		Rules:   rules(importanceFactors),
+		NoFire:  fuzzy.NoFireDefault,
+		Default: 1,
+	}
+}
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+
+	"rabbitMQ/fuzzy"
+)
+
+// Repartition replaces the terms of the Error input of c by a generated
+// partition of its universe with the same term names.
+func Repartition(c *fuzzy.Controller, opts fuzzy.PartitionOptions) error {
+	for _, v := range c.Inputs {
+		if v.Name != Error {
+			continue
+		}
+		opts.Names = make([]string, len(v.Terms))
+		for i, t := range v.Terms {
+			opts.Names[i] = t.Name
+		}
+		terms, err := fuzzy.Partition(v.Min, v.Max, len(v.Terms), opts)
+		if err != nil {
+			return err
+		}
+		v.Terms = terms
+		return nil
+	}
+	return fmt.Errorf("controllers: %s has no %s input", c.Name, Error)
+}
+
+// ErrorShape returns the partition shape of the Error terms of c: gaussian
+// or bell when some term is, triangular when the terms are triangles and
+// trapezoids.
+func ErrorShape(c *fuzzy.Controller) (fuzzy.Shape, error) {
+	for _, v := range c.Inputs {
+		if v.Name != Error {
+			continue
+		}
+		triangular := false
+		for _, t := range v.Terms {
+			switch t.MF.(type) {
+			case fuzzy.Gaussian:
+				return fuzzy.ShapeGaussian, nil
+			case fuzzy.Bell:
+				return fuzzy.ShapeBell, nil
+			case fuzzy.Triangular, fuzzy.Trapezoidal:
+				triangular = true
+			}
+		}
+		if triangular {
+			return fuzzy.ShapeTriangular, nil
+		}
+		return 0, fmt.Errorf("controllers: %s: no partition shape matches the %s terms, set -partition", c.Name, Error)
+	}
+	return 0, fmt.Errorf("controllers: %s has no %s input", c.Name, Error)
+}
+
+// Shoulders turns the outermost terms of the Error input of c, by peak, into
+// open shoulders that stay at full membership from their peak outwards, so
+// errors beyond the large terms keep firing the large rules instead of
+// fading out.
+func Shoulders(c *fuzzy.Controller) error {
+	for _, v := range c.Inputs {
+		if v.Name != Error {
+			continue
+		}
+		if len(v.Terms) == 0 {
+			return nil
+		}
+		first, last := 0, 0
+		for i, t := range v.Terms {
+			if t.MF.Peak() < v.Terms[first].MF.Peak() {
+				first = i
+			}
+			if t.MF.Peak() > v.Terms[last].MF.Peak() {
+				last = i
+			}
+		}
+		left, err := shoulder(v.Terms[first].MF, true)
+		if err != nil {
+			return fmt.Errorf("controllers: %s: term %s: %w", v.Name, v.Terms[first].Name, err)
+		}
+		right, err := shoulder(v.Terms[last].MF, false)
+		if err != nil {
+			return fmt.Errorf("controllers: %s: term %s: %w", v.Name, v.Terms[last].Name, err)
+		}
+		v.Terms[first].MF, v.Terms[last].MF = left, right
+		return nil
+	}
+	return fmt.Errorf("controllers: %s has no %s input", c.Name, Error)
+}
+
+// shoulder returns mf held at full membership from its peak or plateau to
+// minus infinity when left is set, to plus infinity otherwise.
+func shoulder(mf fuzzy.MembershipFunction, left bool) (fuzzy.MembershipFunction, error) {
+	var a, b, c, d float64
+	switch mf := mf.(type) {
+	case fuzzy.Triangular:
+		a, b, c, d = mf.A, mf.B, mf.B, mf.C
+	case fuzzy.Trapezoidal:
+		a, b, c, d = mf.A, mf.B, mf.C, mf.D
+	default:
+		return nil, fmt.Errorf("cannot make a shoulder of a %T", mf)
+	}
+	if left {
+		return fuzzy.Trapezoidal{A: math.Inf(-1), B: math.Inf(-1), C: c, D: d}, nil
+	}
+	return fuzzy.Trapezoidal{A: a, B: b, C: math.Inf(1), D: math.Inf(1)}, nil
+}
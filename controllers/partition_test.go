@@ -0,0 +1,42 @@
+package controllers_test
+
+import (
+	"math"
+	"testing"
+
+	"rabbitMQ/controllers"
+)
+
+// TestShoulders checks that the triangular controller keeps the fading large
+// terms of the original consumer unless Shoulders holds them up.
+func TestShoulders(t *testing.T) {
+	c := controllers.Triangular()
+	degree := func(e float64, term string) float64 {
+		return c.Inputs[0].Fuzzify(e)[term]
+	}
+	if mu := degree(-15000, controllers.LargeNegative); mu != 0.5 {
+		t.Errorf("LN at -15000 = %g, want 0.5", mu)
+	}
+
+	if err := controllers.Shoulders(c); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []float64{-15000, -20000, math.Inf(-1)} {
+		if mu := degree(e, controllers.LargeNegative); mu != 1 {
+			t.Errorf("LN at %g = %g, want 1", e, mu)
+		}
+	}
+	if mu := degree(25000, controllers.LargePositive); mu != 1 {
+		t.Errorf("LP at 25000 = %g, want 1", mu)
+	}
+	if mu := degree(-6250, controllers.LargeNegative); mu != 0.5 {
+		t.Errorf("LN at -6250 = %g, want the inner slope 0.5", mu)
+	}
+	if err := c.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	if err := controllers.Shoulders(controllers.Gaussian()); err == nil {
+		t.Error("Shoulders accepted gaussian terms")
+	}
+}
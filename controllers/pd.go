@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"math"
+
+	"rabbitMQ/fuzzy"
+)
+
+// DeltaError is the change of error between two successive ticker windows,
+// in msg/sec. A negative change means the rate is closing in on the goal
+// from below.
+const DeltaError = "delta_error"
+
+// deltaTerms are the change of error terms, in rule table column order.
+var deltaTerms = []string{LargeNegative, SmallNegative, Zero, SmallPositive, LargePositive}
+
+// pdTable is the two input rule table: one row per error term, one column
+// per change of error term. While the error shrinks the increase is softened
+// and while it grows it is reinforced, damping the oscillation of a rule base
+// driven by the error alone.
+var pdTable = struct {
+	rows  []string
+	table [][]string
+}{
+	rows: []string{LargePositive, MediumPositive, SmallPositive, Zero, SmallNegative, MediumNegative, LargeNegative},
+	table: [][]string{
+		//               LN             SN             ZE             SP             LP
+		/* LP */ {SmallIncrease, LargeIncrease, LargeIncrease, LargeIncrease, LargeIncrease},
+		/* MP */ {Maintain, SmallIncrease, SmallIncrease, LargeIncrease, LargeIncrease},
+		/* SP */ {SmallDecrease, Maintain, SmallIncrease, SmallIncrease, LargeIncrease},
+		/* ZE */ {SmallDecrease, SmallDecrease, Maintain, SmallIncrease, SmallIncrease},
+		/* SN */ {LargeDecrease, SmallDecrease, SmallDecrease, Maintain, SmallIncrease},
+		/* MN */ {LargeDecrease, LargeDecrease, SmallDecrease, SmallDecrease, Maintain},
+		/* LN */ {LargeDecrease, LargeDecrease, LargeDecrease, LargeDecrease, SmallDecrease},
+	},
+}
+
+// AddChangeOfError turns c into a two input, PD-like controller: it adds the
+// DeltaError input with the given terms and replaces the rules by the error
+// × change of error rule table. The table rules are not weighted.
+func AddChangeOfError(c *fuzzy.Controller, terms []fuzzy.Term) {
+	c.Inputs = append(c.Inputs, &fuzzy.Variable{
+		Name:  DeltaError,
+		Min:   -10000,
+		Max:   10000,
+		Terms: terms,
+	})
+	c.Rules = fuzzy.RuleTable(Error, DeltaError, pdTable.rows, deltaTerms, pdTable.table)
+}
+
+// GaussianDelta returns gaussian change of error terms.
+func GaussianDelta() []fuzzy.Term {
+	return []fuzzy.Term{
+		{Name: LargeNegative, MF: fuzzy.Gaussian{Mu: -5000, Sigma: 1750}},
+		{Name: SmallNegative, MF: fuzzy.Gaussian{Mu: -1500, Sigma: 750}},
+		{Name: Zero, MF: fuzzy.Gaussian{Mu: 0, Sigma: 750}},
+		{Name: SmallPositive, MF: fuzzy.Gaussian{Mu: 1500, Sigma: 750}},
+		{Name: LargePositive, MF: fuzzy.Gaussian{Mu: 5000, Sigma: 1750}},
+	}
+}
+
+// TriangularDelta returns triangular change of error terms, with
+// trapezoidal shoulders at both ends.
+func TriangularDelta() []fuzzy.Term {
+	return []fuzzy.Term{
+		{Name: LargeNegative, MF: fuzzy.Trapezoidal{A: math.Inf(-1), B: math.Inf(-1), C: -5000, D: -1500}},
+		{Name: SmallNegative, MF: fuzzy.Triangular{A: -5000, B: -1500, C: 0}},
+		{Name: Zero, MF: fuzzy.Triangular{A: -1500, B: 0, C: 1500}},
+		{Name: SmallPositive, MF: fuzzy.Triangular{A: 0, B: 1500, C: 5000}},
+		{Name: LargePositive, MF: fuzzy.Trapezoidal{A: 1500, B: 5000, C: math.Inf(1), D: math.Inf(1)}},
+	}
+}
+
+// BellDelta returns generalized bell change of error terms.
+func BellDelta() []fuzzy.Term {
+	a, b := 750.0, 1.0
+	return []fuzzy.Term{
+		{Name: LargeNegative, MF: fuzzy.Bell{A: a, B: b, C: -5000}},
+		{Name: SmallNegative, MF: fuzzy.Bell{A: a, B: b, C: -1500}},
+		{Name: Zero, MF: fuzzy.Bell{A: a, B: b, C: 0}},
+		{Name: SmallPositive, MF: fuzzy.Bell{A: a, B: b, C: 1500}},
+		{Name: LargePositive, MF: fuzzy.Bell{A: a, B: b, C: 5000}},
+	}
+}
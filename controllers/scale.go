@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"fmt"
+
+	"rabbitMQ/fuzzy"
+)
+
+// Goal is the crisp input holding the consumer's setpoint, in msg/sec.
+const Goal = "goal"
+
+// Normalize makes c take its error inputs relative to the Goal input rather
+// than in msg/sec: Error, and DeltaError when c has it, are divided by the
+// goal and taken as a percentage (fuzzy.ScalePercent) or clamped to [-1, 1]
+// (fuzzy.ScaleUnit). Their terms, and the error coefficients of TSK
+// consequents, are rescaled from msg/sec at the goal nominal, so that c
+// behaves as before at that goal and proportionally at any other.
+func Normalize(c *fuzzy.Controller, mode fuzzy.ScaleMode, nominal float64) error {
+	if mode != fuzzy.ScalePercent && mode != fuzzy.ScaleUnit {
+		return fmt.Errorf("controllers: cannot normalize the error by %s", mode)
+	}
+	if !(nominal > 0) {
+		return fmt.Errorf("controllers: nominal goal %g is not positive", nominal)
+	}
+	k := 1 / nominal
+	if mode == fuzzy.ScalePercent {
+		k *= 100
+	}
+
+	found := false
+	for _, v := range c.Inputs {
+		if v.Name != Error && v.Name != DeltaError {
+			continue
+		}
+		if err := v.Rescale(k); err != nil {
+			return err
+		}
+		if c.Scales == nil {
+			c.Scales = map[string]fuzzy.InputScale{}
+		}
+		c.Scales[v.Name] = fuzzy.InputScale{Mode: mode, Ref: Goal}
+		for i, r := range c.Rules {
+			if r.TSK == nil {
+				continue
+			}
+			if coeff, ok := r.TSK.Coeffs[v.Name]; ok {
+				// The coefficients may be shared with other rules.
+				l := fuzzy.Linear{Const: r.TSK.Const, Coeffs: map[string]float64{}}
+				for name, x := range r.TSK.Coeffs {
+					l.Coeffs[name] = x
+				}
+				l.Coeffs[v.Name] = coeff / k
+				c.Rules[i].TSK = &l
+			}
+		}
+		if v.Name == Error {
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("controllers: %s has no %s input", c.Name, Error)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package controllers_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"rabbitMQ/controllers"
+	"rabbitMQ/plant"
+	"rabbitMQ/prefetch"
+)
+
+// stepPlant is a plant fast enough for the goals of the consumers: the
+// consumer of the first experiment saturates below 17000 msg/sec. A goal of
+// 10000 msg/sec is reached at a prefetch count of 10 and 25000 at 50.
+var stepPlant = plant.Model{RMax: 40000, K: 30, Tau: 5}
+
+// TestStepResponse closes the loop of every built-in controller around
+// stepPlant and steps the goal up and down. The bounds sit a little above
+// what the controllers achieve today, so that edits to their terms or rules
+// that slow them down, make them overshoot or leave them oscillating fail
+// here.
+func TestStepResponse(t *testing.T) {
+	const (
+		at      = 5    // window the goal steps at
+		windows = 80   // windows of every run
+		band    = 0.1  // settling band, as a fraction of the step
+		tail    = 20   // last windows checked for oscillation
+		swing   = 0.25 // largest peak to peak rate over the tail, as a fraction of the goal
+	)
+	for _, tc := range []struct {
+		variant     string
+		from, to    float64
+		settling    time.Duration
+		overshoot   float64
+		steadyState float64
+	}{
+		{"gaussian", 10000, 25000, 2 * time.Minute, 0.05, 0.02},
+		{"gaussian", 25000, 10000, 8 * time.Minute, 0.15, 0.05},
+		{"triangular", 10000, 25000, 2 * time.Minute, 0.10, 0.02},
+		{"triangular", 25000, 10000, 5 * time.Minute, 0.10, 0.10},
+		{"bell", 10000, 25000, 2 * time.Minute, 0.05, 0.02},
+		{"bell", 25000, 10000, 3 * time.Minute, 0.25, 0.10},
+	} {
+		v, ok := controllers.Lookup(tc.variant)
+		if !ok {
+			t.Fatalf("unknown variant %s", tc.variant)
+		}
+		t.Run(fmt.Sprintf("%s/%.0f-%.0f", tc.variant, tc.from, tc.to), func(t *testing.T) {
+			a, err := prefetch.NewAdjuster(int(math.Round(stepPlant.Prefetch(tc.from))), 1, 100, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := stepPlant.Run(v.New(), a, plant.Step(tc.from, tc.to, at, windows), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			s := r.Step(at, band)
+			if !s.Settled || s.SettlingTime > tc.settling {
+				t.Errorf("settling time %v (settled %v), want at most %v", s.SettlingTime, s.Settled, tc.settling)
+			}
+			if s.Overshoot > tc.overshoot {
+				t.Errorf("overshoot %.1f%%, want at most %.1f%%", 100*s.Overshoot, 100*tc.overshoot)
+			}
+			if s.SteadyStateError > tc.steadyState {
+				t.Errorf("steady-state error %.1f%%, want at most %.1f%%", 100*s.SteadyStateError, 100*tc.steadyState)
+			}
+
+			lo, hi := math.Inf(1), math.Inf(-1)
+			for _, rate := range r.Rate[windows-tail:] {
+				lo, hi = math.Min(lo, rate), math.Max(hi, rate)
+			}
+			if (hi-lo)/tc.to > swing {
+				t.Errorf("rate swings between %.0f and %.0f msg/sec over the last %d windows", lo, hi, tail)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package controllers
+
+import "rabbitMQ/fuzzy"
+
+// Triangular returns the controller with triangular membership functions.
+// As in the original consumer, the large error terms fade out towards
+// ±20000 msg/sec; Shoulders keeps them firing instead.
+func Triangular() *fuzzy.Controller {
+	return &fuzzy.Controller{
+		Name: "triangular",
+		Inputs: []*fuzzy.Variable{{
+			Name: Error,
+			Min:  -20000,
+			Max:  20000,
+			Terms: []fuzzy.Term{
+				{Name: LargeNegative, MF: fuzzy.Triangular{A: -20000, B: -10000, C: -2500}},
+				{Name: MediumNegative, MF: fuzzy.Triangular{A: -5000, B: -2500, C: 0}},
+				{Name: SmallNegative, MF: fuzzy.Triangular{A: -1500, B: -500, C: 0}},
+				{Name: Zero, MF: fuzzy.Triangular{A: -250, B: 0, C: 250}},
+				{Name: SmallPositive, MF: fuzzy.Triangular{A: 0, B: 500, C: 1500}},
+				{Name: MediumPositive, MF: fuzzy.Triangular{A: 0, B: 2500, C: 5000}},
+				{Name: LargePositive, MF: fuzzy.Triangular{A: 2500, B: 10000, C: 20000}},
+			},
+		}},
+		Output: &fuzzy.Variable{
+			Name: Output,
+			Min:  -4,
+			Max:  4,
+			Terms: []fuzzy.Term{
+				{Name: LargeIncrease, MF: fuzzy.Triangular{A: 2.0, B: 3.0, C: 4.0}},
+				{Name: SmallIncrease, MF: fuzzy.Triangular{A: 1.0, B: 2.0, C: 3.0}},
+				{Name: Maintain, MF: fuzzy.Triangular{A: -1.0, B: 0.0, C: 1.0}},
+				{Name: SmallDecrease, MF: fuzzy.Triangular{A: -3.0, B: -2.0, C: -1.0}},
+				{Name: LargeDecrease, MF: fuzzy.Triangular{A: -4.0, B: -3.0, C: -2.0}},
+			},
+		},
+		Rules:   rules(importanceFactors),
+		NoFire:  fuzzy.NoFireDefault,
+		Default: 1,
+	}
+}
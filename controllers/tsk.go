@@ -0,0 +1,66 @@
+package controllers
+
+import "rabbitMQ/fuzzy"
+
+// Crisp inputs available to TSK consequents besides Error.
+const (
+	Rate     = "rate"     // measured rate, in msg/sec
+	Prefetch = "prefetch" // prefetch count currently applied
+)
+
+// tskConsequents maps every error term to the consequent of its TSK rule.
+// The large terms saturate at the peaks of LargeIncrease and LargeDecrease;
+// in between the output grows linearly with the error, which is how the
+// rate responded to the prefetch count in the first experiment.
+var tskConsequents = map[string]fuzzy.Linear{
+	LargePositive:  {Const: 3},
+	MediumPositive: {Const: 1, Coeffs: map[string]float64{Error: 0.0005}},
+	SmallPositive:  {Coeffs: map[string]float64{Error: 0.002}},
+	Zero:           {Coeffs: map[string]float64{Error: 0.002}},
+	SmallNegative:  {Coeffs: map[string]float64{Error: 0.002}},
+	MediumNegative: {Const: -1, Coeffs: map[string]float64{Error: 0.0005}},
+	LargeNegative:  {Const: -3},
+}
+
+// ToTSK turns c into a Takagi-Sugeno-Kang controller in place. The error
+// terms and rule weights are kept; every consequent is replaced by the TSK
+// consequent of the rule's error term, and the defuzzifier by the weighted
+// average.
+func ToTSK(c *fuzzy.Controller) {
+	for i, r := range c.Rules {
+		term, ok := errorTerm(r.If)
+		if !ok {
+			continue
+		}
+		if l, ok := tskConsequents[term]; ok {
+			// Every rule gets its own coefficients, so that tuning or
+			// normalizing one controller leaves the others alone.
+			if l.Coeffs != nil {
+				coeffs := make(map[string]float64, len(l.Coeffs))
+				for name, k := range l.Coeffs {
+					coeffs[name] = k
+				}
+				l.Coeffs = coeffs
+			}
+			c.Rules[i].TSK = &l
+		}
+	}
+	c.Defuzzifier = fuzzy.WeightedAverage{}
+}
+
+// errorTerm returns the term the antecedent a requires of Error.
+func errorTerm(a fuzzy.Antecedent) (string, bool) {
+	switch a := a.(type) {
+	case fuzzy.Is:
+		if a.Var == Error && len(a.Hedges) == 0 {
+			return a.Term, true
+		}
+	case fuzzy.And:
+		for _, c := range a {
+			if t, ok := errorTerm(c); ok {
+				return t, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,32 @@
+package controllers_test
+
+import (
+	"testing"
+
+	"rabbitMQ/controllers"
+)
+
+// TestToTSKCopiesCoefficients checks that controllers turned into TSK ones
+// do not share the coefficients of their consequents.
+func TestToTSKCopiesCoefficients(t *testing.T) {
+	a, b := controllers.Triangular(), controllers.Triangular()
+	controllers.ToTSK(a)
+	controllers.ToTSK(b)
+	for i, r := range a.Rules {
+		if r.TSK == nil || r.TSK.Coeffs == nil {
+			continue
+		}
+		r.TSK.Coeffs[controllers.Error] *= 10
+		if got := b.Rules[i].TSK.Coeffs[controllers.Error]; got == r.TSK.Coeffs[controllers.Error] {
+			t.Errorf("rule %d: coefficient %g changed with the other controller", i+1, got)
+		}
+	}
+
+	c := controllers.Triangular()
+	controllers.ToTSK(c)
+	for i, r := range c.Rules {
+		if r.TSK != nil && r.TSK.Coeffs != nil && r.TSK.Coeffs[controllers.Error] != b.Rules[i].TSK.Coeffs[controllers.Error] {
+			t.Errorf("rule %d: new controller has coefficient %g, want %g", i+1, r.TSK.Coeffs[controllers.Error], b.Rules[i].TSK.Coeffs[controllers.Error])
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+
+	"rabbitMQ/fuzzy"
+)
+
+// ToType2 turns every input term of c into an interval type-2 term in
+// place, whose width is uncertain by the fraction spread either way: the
+// upper membership function is the term widened by 1+spread around its
+// peak and the lower one the term narrowed by 1-spread. The footprint of
+// uncertainty this leaves around every term stands for the noise of the
+// rate measured from window to window.
+func ToType2(c *fuzzy.Controller, spread float64) error {
+	if !(spread > 0 && spread < 1) {
+		return fmt.Errorf("controllers: type-2 spread %g is outside (0, 1)", spread)
+	}
+	for _, v := range c.Inputs {
+		terms := make([]fuzzy.Term, len(v.Terms))
+		for i, t := range v.Terms {
+			upper, err := widen(t.MF, 1+spread)
+			if err != nil {
+				return fmt.Errorf("controllers: %s: term %s: %w", v.Name, t.Name, err)
+			}
+			lower, err := widen(t.MF, 1-spread)
+			if err != nil {
+				return fmt.Errorf("controllers: %s: term %s: %w", v.Name, t.Name, err)
+			}
+			terms[i] = fuzzy.Term{Name: t.Name, MF: upper, Lower: lower}
+		}
+		v.Terms = terms
+	}
+	return nil
+}
+
+// widen scales the slopes of mf by k about its peak or plateau. Infinite
+// corners of shoulders stay where they are.
+func widen(mf fuzzy.MembershipFunction, k float64) (fuzzy.MembershipFunction, error) {
+	away := func(peak, x float64) float64 {
+		if math.IsInf(x, 0) {
+			return x
+		}
+		return peak + k*(x-peak)
+	}
+	switch mf := mf.(type) {
+	case fuzzy.Triangular:
+		return fuzzy.Triangular{A: away(mf.B, mf.A), B: mf.B, C: away(mf.B, mf.C)}, nil
+	case fuzzy.Trapezoidal:
+		return fuzzy.Trapezoidal{A: away(mf.B, mf.A), B: mf.B, C: mf.C, D: away(mf.C, mf.D)}, nil
+	case fuzzy.Gaussian:
+		return fuzzy.Gaussian{Mu: mf.Mu, Sigma: mf.Sigma * k}, nil
+	case fuzzy.Bell:
+		return fuzzy.Bell{A: mf.A * k, B: mf.B, C: mf.C}, nil
+	}
+	return nil, fmt.Errorf("cannot make a %T type-2", mf)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"os"
+
+	"rabbitMQ/controllers"
+	"rabbitMQ/fuzzy"
+	"rabbitMQ/internal/cli"
+)
+
+func failOnError(err error, msg string) {
+	if err != nil {
+		log.Fatalf("%s: %s", msg, err)
+	}
+}
+
+// fcl writes a consumer's controller, with the same controller flags as the
+// consumer, as a Fuzzy Control Language function block.
+func main() {
+	variantName := flag.String("variant", "gaussian", "built-in controller: gaussian, triangular or bell")
+	out := flag.String("o", "", "output file (default stdout)")
+	controllerFlags := controllers.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	variant, ok := controllers.Lookup(*variantName)
+	if !ok {
+		log.Fatalf("Unknown variant %q", *variantName)
+	}
+	c, err := controllerFlags.Controller(variant)
+	failOnError(err, "Failed to build the fuzzy controller")
+
+	if *out == "" {
+		err = fuzzy.WriteFCL(os.Stdout, c)
+		failOnError(err, "Failed to write FCL")
+		return
+	}
+
+	err = cli.WriteFile(*out, func(w io.Writer) error { return fuzzy.WriteFCL(w, c) })
+	failOnError(err, "Failed to write FCL")
+}
@@ -0,0 +1,239 @@
+package fuzzy
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// AnalysisOptions tune Analyze.
+type AnalysisOptions struct {
+	// Samples is the number of points each input universe is swept at.
+	Samples int
+	// MinStrength is the total firing strength below which a point of the
+	// input space counts as a gap.
+	MinStrength float64
+	// MaxOverlap is the similarity above which two terms of the same
+	// variable count as redundant. Similarity is the area of the
+	// intersection of the terms over the area of their union.
+	MaxOverlap float64
+}
+
+// Gap is a run of input points, along the first input, where the total
+// firing strength of the rules stays below AnalysisOptions.MinStrength.
+type Gap struct {
+	From, To    map[string]float64
+	MinStrength float64
+}
+
+// Overlap is a pair of terms of the same variable that are nearly the same
+// fuzzy set.
+type Overlap struct {
+	Var          string
+	Term1, Term2 string
+	Similarity   float64
+}
+
+// Conflict is a set of rules with the same antecedent but different
+// consequents.
+type Conflict struct {
+	If    string
+	Rules []int // 1-based rule numbers
+}
+
+// Report is the result of Analyze.
+type Report struct {
+	Gaps        []Gap
+	Overlaps    []Overlap
+	Conflicts   []Conflict
+	Duplicates  []Conflict // rules repeated with the same consequent
+	UnusedTerms []string   // "var.term" of terms no rule refers to
+}
+
+// Failed reports whether the rule base has gaps or conflicting rules.
+// Overlaps, duplicates and unused terms are only warnings.
+func (r *Report) Failed() bool {
+	return len(r.Gaps) > 0 || len(r.Conflicts) > 0
+}
+
+// Warned reports whether the rule base has overlapping terms, duplicate
+// rules or unused terms.
+func (r *Report) Warned() bool {
+	return len(r.Overlaps) > 0 || len(r.Duplicates) > 0 || len(r.UnusedTerms) > 0
+}
+
+// Analyze sweeps the input space of c and reports the gaps of its rule base,
+// overlapping terms and conflicting or duplicate rules.
+func Analyze(c *Controller, opts AnalysisOptions) (*Report, error) {
+	if opts.Samples < 2 {
+		opts.Samples = 2
+	}
+	r := &Report{}
+	if err := r.findGaps(c, opts); err != nil {
+		return nil, err
+	}
+	for _, v := range append(append([]*Variable{}, c.Inputs...), c.Output) {
+		r.Overlaps = append(r.Overlaps, overlaps(v, opts)...)
+	}
+	r.findConflicts(c)
+	r.findUnused(c)
+	return r, nil
+}
+
+func (r *Report) findGaps(c *Controller, opts AnalysisOptions) error {
+	n := opts.Samples
+	idx := make([]int, len(c.Inputs))
+	point := func() map[string]float64 {
+		in := make(map[string]float64, len(c.Inputs))
+		for i, v := range c.Inputs {
+			in[v.Name] = v.Min + float64(idx[i])*(v.Max-v.Min)/float64(n-1)
+		}
+		return in
+	}
+
+	var open *Gap
+	for {
+		in := point()
+		m, err := c.Fuzzify(in)
+		if err != nil {
+			return err
+		}
+		total := 0.0
+		for _, rule := range c.Rules {
+			total += rule.If.Degree(m, c.Operators)
+		}
+
+		if total < opts.MinStrength {
+			if open == nil {
+				open = &Gap{From: in, MinStrength: total}
+			}
+			open.To = in
+			open.MinStrength = math.Min(open.MinStrength, total)
+		} else if open != nil {
+			r.Gaps = append(r.Gaps, *open)
+			open = nil
+		}
+
+		// Advance the first input fastest; a run never spans two lines.
+		i := 0
+		for ; i < len(idx); i++ {
+			idx[i]++
+			if idx[i] < n {
+				break
+			}
+			idx[i] = 0
+		}
+		if i > 0 && open != nil {
+			r.Gaps = append(r.Gaps, *open)
+			open = nil
+		}
+		if i == len(idx) {
+			return nil
+		}
+	}
+}
+
+func overlaps(v *Variable, opts AnalysisOptions) []Overlap {
+	var os []Overlap
+	step := (v.Max - v.Min) / float64(opts.Samples-1)
+	for i := 0; i < len(v.Terms); i++ {
+		for j := i + 1; j < len(v.Terms); j++ {
+			inter, union := 0.0, 0.0
+			for k := 0; k < opts.Samples; k++ {
+				x := v.Min + float64(k)*step
+				a, b := v.Terms[i].MF.Eval(x), v.Terms[j].MF.Eval(x)
+				inter += math.Min(a, b)
+				union += math.Max(a, b)
+			}
+			if union == 0 {
+				continue
+			}
+			if s := inter / union; s >= opts.MaxOverlap {
+				os = append(os, Overlap{Var: v.Name, Term1: v.Terms[i].Name, Term2: v.Terms[j].Name, Similarity: s})
+			}
+		}
+	}
+	return os
+}
+
+// antecedentKey identifies an antecedent regardless of the order of the
+// operands of its ANDs and ORs.
+func antecedentKey(a Antecedent) string {
+	var ops []Antecedent
+	sep := ""
+	switch a := a.(type) {
+	case And:
+		ops, sep = a, " AND "
+	case Or:
+		ops, sep = a, " OR "
+	case Not:
+		return "NOT (" + antecedentKey(a.A) + ")"
+	default:
+		return a.String()
+	}
+	parts := make([]string, len(ops))
+	for i, op := range ops {
+		parts[i] = antecedentKey(op)
+		switch op.(type) {
+		case And, Or:
+			parts[i] = "(" + parts[i] + ")"
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, sep)
+}
+
+func (r *Report) findConflicts(c *Controller) {
+	byIf := map[string][]int{}
+	var keys []string
+	for i, rule := range c.Rules {
+		k := antecedentKey(rule.If)
+		if _, ok := byIf[k]; !ok {
+			keys = append(keys, k)
+		}
+		byIf[k] = append(byIf[k], i)
+	}
+
+	for _, k := range keys {
+		rs := byIf[k]
+		if len(rs) < 2 {
+			continue
+		}
+		same := true
+		for _, i := range rs[1:] {
+			a, b := c.Rules[rs[0]], c.Rules[i]
+			if a.Then != b.Then || fmt.Sprint(a.TSK) != fmt.Sprint(b.TSK) {
+				same = false
+			}
+		}
+		numbers := make([]int, len(rs))
+		for i, ri := range rs {
+			numbers[i] = ri + 1
+		}
+		if same {
+			r.Duplicates = append(r.Duplicates, Conflict{If: k, Rules: numbers})
+		} else {
+			r.Conflicts = append(r.Conflicts, Conflict{If: k, Rules: numbers})
+		}
+	}
+}
+
+func (r *Report) findUnused(c *Controller) {
+	used := map[string]bool{}
+	for _, rule := range c.Rules {
+		for _, is := range clauses(rule.If) {
+			used[is.Var+"."+is.Term] = true
+		}
+		if rule.TSK == nil {
+			used[c.Output.Name+"."+rule.Then] = true
+		}
+	}
+	for _, v := range append(append([]*Variable{}, c.Inputs...), c.Output) {
+		for _, t := range v.Terms {
+			if k := v.Name + "." + t.Name; !used[k] {
+				r.UnusedTerms = append(r.UnusedTerms, k)
+			}
+		}
+	}
+}
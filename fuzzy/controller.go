@@ -0,0 +1,225 @@
+// Package fuzzy implements the fuzzy inference engine shared by the prefetch
+// controllers: linguistic variables, terms, rules and their evaluation.
+package fuzzy
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrNoRuleFired is returned by Evaluate under the NoFireError policy.
+var ErrNoRuleFired = errors.New("fuzzy: no rule fired")
+
+// NoFirePolicy decides the output of a controller when no rule fires.
+type NoFirePolicy int
+
+const (
+	NoFireDefault NoFirePolicy = iota // return Controller.Default
+	NoFireHold                        // return the previous output, or Default before the first one
+	NoFireError                       // return ErrNoRuleFired
+)
+
+var noFireNames = map[NoFirePolicy]string{NoFireDefault: "default", NoFireHold: "hold", NoFireError: "error"}
+
+func (p NoFirePolicy) String() string { return noFireNames[p] }
+
+// Set implements flag.Value.
+func (p *NoFirePolicy) Set(s string) error {
+	for k, name := range noFireNames {
+		if name == s {
+			*p = k
+			return nil
+		}
+	}
+	return fmt.Errorf("fuzzy: unknown no-fire policy %q", s)
+}
+
+// Controller is a fuzzy inference engine: it fuzzifies its inputs, fires
+// every rule and defuzzifies the result into a single crisp output.
+//
+// A Controller keeps its previous output for NoFireHold, so it must not be
+// evaluated from several goroutines at once.
+type Controller struct {
+	Name   string
+	Inputs []*Variable
+	Output *Variable
+	Rules  []Rule
+
+	// Crisp names the crisp inputs, besides the input variables, that the
+	// linear consequents of TSK rules may weigh, such as the prefetch count.
+	Crisp []string
+
+	// Defuzzifier turns the fired rules into the crisp output. Nil means
+	// WeightedAverage, the method the consumers were originally written with.
+	Defuzzifier Defuzzifier
+
+	// NoFire and Default decide the output when no rule fires.
+	NoFire  NoFirePolicy
+	Default float64
+
+	// Scales normalize crisp inputs, by input name, before they are
+	// fuzzified. OutputGain multiplies the defuzzified output; zero means 1.
+	Scales     map[string]InputScale
+	OutputGain float64
+
+	// Operators combine the clauses of compound antecedents.
+	Operators Operators
+
+	// Implication, Aggregation and Resolution build the output set sampled
+	// by surface based defuzzifiers such as Centroid.
+	Implication Implication
+	Aggregation Aggregation
+	Resolution  int
+
+	// PeakResolution is the number of intervals the output universe is
+	// split into when searching for the peak of each output term, the
+	// representative value of its consequents. Zero takes the exact peak
+	// of the membership function.
+	PeakResolution int
+
+	peaks   map[string]float64 // by output term name, see Prepare
+	last    float64
+	hasLast bool
+}
+
+// Fuzzify returns the membership degrees of every input in in.
+func (c *Controller) Fuzzify(in map[string]float64) (Memberships, error) {
+	m := make(Memberships, len(c.Inputs))
+	for _, v := range c.Inputs {
+		x, ok := in[v.Name]
+		if !ok {
+			return nil, fmt.Errorf("fuzzy: missing input %q", v.Name)
+		}
+		m[v.Name] = v.Fuzzify(x)
+	}
+	return m, nil
+}
+
+// Fire scales and fuzzifies in and fires every rule, without defuzzifying.
+func (c *Controller) Fire(in map[string]float64) (*Output, error) {
+	in, err := c.ScaleInputs(in)
+	if err != nil {
+		return nil, err
+	}
+	m, err := c.Fuzzify(in)
+	if err != nil {
+		return nil, err
+	}
+	var lower Memberships
+	if c.Type2() {
+		lower = c.fuzzifyLower(in)
+	}
+
+	if c.peaks == nil {
+		c.Prepare()
+	}
+
+	out := &Output{c: c, in: in, type2: lower != nil, Activations: make([]Activation, 0, len(c.Rules))}
+	for _, r := range c.Rules {
+		a := Activation{Rule: r, Strength: r.If.Degree(m, c.Operators)}
+		a.Lower = a.Strength
+		if lower != nil {
+			a.Lower, a.Strength = interval(r.If, lower, m, c.Operators)
+		}
+		if r.TSK != nil {
+			if a.Value, err = r.TSK.Eval(in); err != nil {
+				return nil, err
+			}
+			a.Value *= r.gain()
+			out.tsk = true
+		} else {
+			t, ok := c.Output.Term(r.Then)
+			if !ok {
+				return nil, fmt.Errorf("fuzzy: unknown output term %q", r.Then)
+			}
+			a.Term, a.Value = t, c.peaks[t.Name]*r.gain()
+		}
+		out.Activations = append(out.Activations, a)
+	}
+	return out, nil
+}
+
+// Evaluate runs the controller on the crisp inputs in, keyed by variable
+// name. in may hold more values than the controller has input variables, for
+// use by TSK consequents.
+func (c *Controller) Evaluate(in map[string]float64) (float64, error) {
+	u, _, _, err := c.evaluate(in)
+	return u, err
+}
+
+// evaluate is Evaluate, also returning the fired rules, when firing them
+// succeeded, and whether any rule fired.
+func (c *Controller) evaluate(in map[string]float64) (float64, *Output, bool, error) {
+	out, err := c.Fire(in)
+	if err != nil {
+		return 0, nil, false, err
+	}
+
+	d := c.defuzzifier()
+	if _, ok := d.(WeightedAverage); out.tsk && !ok {
+		return 0, nil, false, fmt.Errorf("fuzzy: TSK rules need the weighted-average defuzzifier, not %s", d)
+	}
+	if _, ok := d.(WeightedAverage); out.type2 && !ok {
+		return 0, nil, false, fmt.Errorf("fuzzy: type-2 terms need the weighted-average defuzzifier, not %s", d)
+	}
+
+	u, ok := d.Defuzzify(out)
+	if !ok {
+		u, err := c.noFire()
+		return u, out, false, err
+	}
+	u *= c.outputGain()
+	c.last, c.hasLast = u, true
+	return u, out, true, nil
+}
+
+func (c *Controller) defuzzifier() Defuzzifier {
+	if c.Defuzzifier == nil {
+		return WeightedAverage{}
+	}
+	return c.Defuzzifier
+}
+
+func (c *Controller) noFire() (float64, error) {
+	switch c.NoFire {
+	case NoFireHold:
+		if c.hasLast {
+			return c.last, nil
+		}
+		return c.Default, nil
+	case NoFireError:
+		return 0, ErrNoRuleFired
+	default:
+		return c.Default, nil
+	}
+}
+
+// Prepare computes the representative value of every output term once, so
+// that evaluations do not search for it. Fire prepares the controller on
+// first use; call Prepare again after changing Output or PeakResolution.
+func (c *Controller) Prepare() {
+	c.peaks = make(map[string]float64, len(c.Output.Terms))
+	for _, t := range c.Output.Terms {
+		c.peaks[t.Name] = c.peak(t)
+	}
+}
+
+// peak returns the point of the output universe where t reaches its
+// highest membership.
+func (c *Controller) peak(t Term) float64 {
+	lo, hi := c.Output.Min, c.Output.Max
+	if c.PeakResolution <= 0 {
+		return math.Max(lo, math.Min(hi, t.MF.Peak()))
+	}
+
+	step := (hi - lo) / float64(c.PeakResolution)
+	r, max := lo, t.MF.Eval(lo)
+	for i := 1; i <= c.PeakResolution; i++ {
+		x := lo + float64(i)*step
+		if v := t.MF.Eval(x); v > max {
+			max, r = v, x
+		}
+	}
+	return r
+}
@@ -0,0 +1,87 @@
+package fuzzy
+
+import (
+	"fmt"
+	"testing"
+)
+
+// benchController is shaped like the consumers' gaussian controller: seven
+// error terms, five output terms and one rule per error term.
+func benchController() *Controller {
+	errTerms := []string{"LN", "MN", "SN", "ZE", "SP", "MP", "LP"}
+	outTerms := []string{"LD", "LD", "SD", "MAINTAIN", "SI", "LI", "LI"}
+	c := &Controller{
+		Inputs: []*Variable{{Name: "error", Min: -20000, Max: 20000}},
+		Output: &Variable{Name: "output", Min: -4, Max: 4, Terms: []Term{
+			{Name: "LD", MF: Gaussian{-2, 0.5}},
+			{Name: "SD", MF: Gaussian{-1.5, 0.5}},
+			{Name: "MAINTAIN", MF: Gaussian{0, 0.5}},
+			{Name: "SI", MF: Gaussian{1.5, 0.5}},
+			{Name: "LI", MF: Gaussian{3, 0.5}},
+		}},
+	}
+	for i, name := range errTerms {
+		mu := float64(i-3) * 2500
+		c.Inputs[0].Terms = append(c.Inputs[0].Terms, Term{Name: name, MF: Gaussian{mu, 1500}})
+		c.Rules = append(c.Rules, Rule{If: Is{Var: "error", Term: name}, Then: outTerms[i]})
+	}
+	return c
+}
+
+// BenchmarkEvaluate compares searching the output terms for their peaks on
+// every evaluation, as the consumers used to, with preparing them once.
+func BenchmarkEvaluate(b *testing.B) {
+	in := map[string]float64{"error": 1200}
+	for _, bc := range []struct {
+		name       string
+		resolution int
+		everyTime  bool
+	}{
+		{"scan-per-evaluation", 16, true},
+		{"scan-prepared", 16, false},
+		{"exact-prepared", 0, false},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			c := benchController()
+			c.PeakResolution = bc.resolution
+			for i := 0; i < b.N; i++ {
+				if bc.everyTime {
+					c.peaks = nil
+				}
+				if _, err := c.Evaluate(in); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// TestPreparedPeaks checks the peaks Prepare finds: the exact peak of every
+// term at PeakResolution 0, and the best grid point otherwise, which differs
+// for OFF, a triangle peaking at 0.3 between the points 0 and 0.5 of the
+// 16 interval grid.
+func TestPreparedPeaks(t *testing.T) {
+	for _, tc := range []struct {
+		res int
+		off float64
+	}{
+		{0, 0.3},
+		{16, 0.5},
+	} {
+		t.Run(fmt.Sprint(tc.res), func(t *testing.T) {
+			c := benchController()
+			c.Output.Terms = append(c.Output.Terms, Term{Name: "OFF", MF: Triangular{-0.2, 0.3, 0.8}})
+			c.PeakResolution = tc.res
+			c.Prepare()
+			for _, term := range c.Output.Terms {
+				want := term.MF.Peak()
+				if term.Name == "OFF" {
+					want = tc.off
+				}
+				if got := c.peaks[term.Name]; got != want {
+					t.Errorf("peak of %s = %g, want %g", term.Name, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,396 @@
+package fuzzy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Definition is the declarative form of a controller, as read from and
+// written to JSON or YAML files. Crisp lists the crisp inputs, besides the
+// input variables, that TSK consequents may weigh, as in Controller.
+type Definition struct {
+	Name           string              `json:"name,omitempty" yaml:"name,omitempty"`
+	Inputs         []VariableDef       `json:"inputs" yaml:"inputs"`
+	Output         VariableDef         `json:"output" yaml:"output"`
+	Rules          []RuleDef           `json:"rules" yaml:"rules"`
+	Crisp          []string            `json:"crisp,omitempty" yaml:"crisp,omitempty,flow"`
+	Defuzzifier    string              `json:"defuzzifier,omitempty" yaml:"defuzzifier,omitempty"`
+	Operators      string              `json:"operators,omitempty" yaml:"operators,omitempty"`
+	Implication    string              `json:"implication,omitempty" yaml:"implication,omitempty"`
+	Aggregation    string              `json:"aggregation,omitempty" yaml:"aggregation,omitempty"`
+	Resolution     int                 `json:"resolution,omitempty" yaml:"resolution,omitempty"`
+	PeakResolution int                 `json:"peakResolution,omitempty" yaml:"peakResolution,omitempty"`
+	Scales         map[string]ScaleDef `json:"scales,omitempty" yaml:"scales,omitempty"`
+	OutputGain     float64             `json:"outputGain,omitempty" yaml:"outputGain,omitempty"`
+	NoFire         string              `json:"noFire,omitempty" yaml:"noFire,omitempty"`
+	Default        float64             `json:"default" yaml:"default"`
+}
+
+// ScaleDef describes the InputScale of an input: Mode is gain, percent or
+// unit.
+type ScaleDef struct {
+	Mode   string  `json:"mode" yaml:"mode"`
+	Ref    string  `json:"ref,omitempty" yaml:"ref,omitempty"`
+	Factor float64 `json:"factor,omitempty" yaml:"factor,omitempty"`
+}
+
+// VariableDef describes a linguistic variable over the universe Range.
+type VariableDef struct {
+	Name  string     `json:"name" yaml:"name"`
+	Range [2]float64 `json:"range" yaml:"range,flow"`
+	Terms []TermDef  `json:"terms" yaml:"terms"`
+}
+
+// TermDef describes a term by the Type of its membership function and its
+// Params, in the order of the fields of the matching MembershipFunction:
+//
+//	triangular  a b c        trapezoidal a b c d
+//	gaussian    mu sigma     bell        a b c
+//	sigmoid     a c          dsigmoid    a1 c1 a2 c2
+//	pi          a b c d      s, z        a b
+//	singleton   x            points      x1 y1 x2 y2 ...
+//
+// Lower, when set, makes the term interval type-2: it holds the params of
+// its lower membership function, of the same Type.
+type TermDef struct {
+	Name   string    `json:"name" yaml:"name"`
+	Type   string    `json:"type" yaml:"type"`
+	Params []float64 `json:"params" yaml:"params,flow"`
+	Lower  []float64 `json:"lower,omitempty" yaml:"lower,omitempty,flow"`
+}
+
+// RuleDef describes a rule. If uses the FCL rule syntax, e.g.
+// "error IS LP AND (delta_error IS ZE OR delta_error IS SP)". Then names an
+// output term, unless TSK is set. Weight scales the firing strength and Gain
+// the consequent, as in Rule; Gain plays the role of the importance factors
+// of the original consumers. Both default to 1 when left out, and an explicit
+// 0 is rejected rather than read as unset.
+type RuleDef struct {
+	If     string   `json:"if" yaml:"if"`
+	Then   string   `json:"then,omitempty" yaml:"then,omitempty"`
+	TSK    *Linear  `json:"tsk,omitempty" yaml:"tsk,omitempty"`
+	Weight *float64 `json:"weight,omitempty" yaml:"weight,omitempty"`
+	Gain   *float64 `json:"gain,omitempty" yaml:"gain,omitempty"`
+}
+
+// mfArity is the number of parameters of every fixed shape term type.
+var mfArity = map[string]int{
+	"triangular": 3, "trapezoidal": 4, "gaussian": 2, "bell": 3, "sigmoid": 2,
+	"dsigmoid": 4, "pi": 4, "s": 2, "z": 2, "singleton": 1,
+}
+
+func (t TermDef) membership() (MembershipFunction, error) {
+	p := t.Params
+	if t.Type == "points" {
+		if len(p) == 0 || len(p)%2 != 0 {
+			return nil, fmt.Errorf("points needs x y pairs, got %d params", len(p))
+		}
+		pts := make(PiecewiseLinear, len(p)/2)
+		for i := range pts {
+			pts[i] = Point{p[2*i], p[2*i+1]}
+		}
+		return pts, nil
+	}
+
+	n, ok := mfArity[t.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown membership function type %q", t.Type)
+	}
+	if len(p) != n {
+		return nil, fmt.Errorf("%s needs %d params, got %d", t.Type, n, len(p))
+	}
+	switch t.Type {
+	case "triangular":
+		return Triangular{p[0], p[1], p[2]}, nil
+	case "trapezoidal":
+		return Trapezoidal{p[0], p[1], p[2], p[3]}, nil
+	case "gaussian":
+		return Gaussian{p[0], p[1]}, nil
+	case "bell":
+		return Bell{p[0], p[1], p[2]}, nil
+	case "sigmoid":
+		return Sigmoid{p[0], p[1]}, nil
+	case "dsigmoid":
+		return DiffSigmoid{p[0], p[1], p[2], p[3]}, nil
+	case "pi":
+		return Pi{p[0], p[1], p[2], p[3]}, nil
+	case "s":
+		return SShape{p[0], p[1]}, nil
+	case "z":
+		return ZShape{p[0], p[1]}, nil
+	default:
+		return Singleton{p[0]}, nil
+	}
+}
+
+func termDef(t Term) (TermDef, error) {
+	if t.Lower != nil {
+		d, err := termDef(Term{Name: t.Name, MF: t.MF})
+		if err != nil {
+			return d, err
+		}
+		lower, err := termDef(Term{Name: t.Name, MF: t.Lower})
+		if err != nil {
+			return d, err
+		}
+		if lower.Type != d.Type {
+			return d, fmt.Errorf("term %s: lower membership function is %s, not %s", t.Name, lower.Type, d.Type)
+		}
+		d.Lower = lower.Params
+		return d, nil
+	}
+
+	d := TermDef{Name: t.Name}
+	switch mf := t.MF.(type) {
+	case Triangular:
+		d.Type, d.Params = "triangular", []float64{mf.A, mf.B, mf.C}
+	case Trapezoidal:
+		if anyInf(mf.A, mf.B, mf.C, mf.D) {
+			// JSON has no infinity: shoulders become point lists.
+			var pts PiecewiseLinear
+			for _, pt := range []Point{{mf.A, 0}, {mf.B, 1}, {mf.C, 1}, {mf.D, 0}} {
+				if !math.IsInf(pt.X, 0) {
+					pts = append(pts, pt)
+				}
+			}
+			return termDef(Term{Name: t.Name, MF: pts})
+		}
+		d.Type, d.Params = "trapezoidal", []float64{mf.A, mf.B, mf.C, mf.D}
+	case Gaussian:
+		d.Type, d.Params = "gaussian", []float64{mf.Mu, mf.Sigma}
+	case Bell:
+		d.Type, d.Params = "bell", []float64{mf.A, mf.B, mf.C}
+	case Sigmoid:
+		d.Type, d.Params = "sigmoid", []float64{mf.A, mf.C}
+	case DiffSigmoid:
+		d.Type, d.Params = "dsigmoid", []float64{mf.A1, mf.C1, mf.A2, mf.C2}
+	case Pi:
+		d.Type, d.Params = "pi", []float64{mf.A, mf.B, mf.C, mf.D}
+	case SShape:
+		d.Type, d.Params = "s", []float64{mf.A, mf.B}
+	case ZShape:
+		d.Type, d.Params = "z", []float64{mf.A, mf.B}
+	case Singleton:
+		d.Type, d.Params = "singleton", []float64{mf.X}
+	case PiecewiseLinear:
+		d.Type = "points"
+		for _, pt := range mf {
+			d.Params = append(d.Params, pt.X, pt.Y)
+		}
+	default:
+		return d, fmt.Errorf("term %s: unsupported membership function %T", t.Name, mf)
+	}
+	return d, nil
+}
+
+func (vd VariableDef) variable() (*Variable, error) {
+	v := &Variable{Name: vd.Name, Min: vd.Range[0], Max: vd.Range[1]}
+	for _, td := range vd.Terms {
+		mf, err := td.membership()
+		if err != nil {
+			return nil, fmt.Errorf("%s: term %s: %w", vd.Name, td.Name, err)
+		}
+		t := Term{Name: td.Name, MF: mf}
+		if td.Lower != nil {
+			lower := TermDef{Type: td.Type, Params: td.Lower}
+			if t.Lower, err = lower.membership(); err != nil {
+				return nil, fmt.Errorf("%s: term %s: lower: %w", vd.Name, td.Name, err)
+			}
+		}
+		v.Terms = append(v.Terms, t)
+	}
+	return v, nil
+}
+
+func variableDef(v *Variable) (VariableDef, error) {
+	vd := VariableDef{Name: v.Name, Range: [2]float64{v.Min, v.Max}}
+	for _, t := range v.Terms {
+		td, err := termDef(t)
+		if err != nil {
+			return vd, fmt.Errorf("%s: %w", v.Name, err)
+		}
+		vd.Terms = append(vd.Terms, td)
+	}
+	return vd, nil
+}
+
+// Controller builds and validates the controller d describes.
+func (d *Definition) Controller() (*Controller, error) {
+	c := &Controller{Name: d.Name, Crisp: d.Crisp, Resolution: d.Resolution, PeakResolution: d.PeakResolution, Default: d.Default}
+	for _, vd := range d.Inputs {
+		v, err := vd.variable()
+		if err != nil {
+			return nil, fmt.Errorf("fuzzy: %w", err)
+		}
+		c.Inputs = append(c.Inputs, v)
+	}
+	out, err := d.Output.variable()
+	if err != nil {
+		return nil, fmt.Errorf("fuzzy: %w", err)
+	}
+	c.Output = out
+
+	if d.Defuzzifier != "" {
+		if c.Defuzzifier, err = ParseDefuzzifier(d.Defuzzifier); err != nil {
+			return nil, err
+		}
+	}
+	if d.Operators != "" {
+		if err := c.Operators.Set(d.Operators); err != nil {
+			return nil, err
+		}
+	}
+	if d.Implication != "" {
+		if err := c.Implication.Set(d.Implication); err != nil {
+			return nil, err
+		}
+	}
+	if d.Aggregation != "" {
+		if err := c.Aggregation.Set(d.Aggregation); err != nil {
+			return nil, err
+		}
+	}
+	if d.NoFire != "" {
+		if err := c.NoFire.Set(d.NoFire); err != nil {
+			return nil, err
+		}
+	}
+	c.OutputGain = d.OutputGain
+	for name, sd := range d.Scales {
+		s := InputScale{Ref: sd.Ref, Factor: sd.Factor}
+		if err := s.Mode.Set(sd.Mode); err != nil {
+			return nil, err
+		}
+		if c.Scales == nil {
+			c.Scales = map[string]InputScale{}
+		}
+		c.Scales[name] = s
+	}
+
+	for i, rd := range d.Rules {
+		cond, err := ParseAntecedent(rd.If)
+		if err != nil {
+			return nil, fmt.Errorf("fuzzy: rule %d: %w", i+1, err)
+		}
+		r := Rule{If: cond, Then: rd.Then, TSK: rd.TSK}
+		if rd.Weight != nil {
+			if *rd.Weight == 0 {
+				return nil, fmt.Errorf("fuzzy: rule %d: weight 0 never fires the rule, remove the rule instead", i+1)
+			}
+			r.Weight = *rd.Weight
+		}
+		if rd.Gain != nil {
+			if *rd.Gain == 0 {
+				return nil, fmt.Errorf("fuzzy: rule %d: gain 0 cancels the consequent, remove the rule instead", i+1)
+			}
+			r.Gain = *rd.Gain
+		}
+		c.Rules = append(c.Rules, r)
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// NewDefinition returns the declarative form of c.
+func NewDefinition(c *Controller) (*Definition, error) {
+	d := &Definition{
+		Name:           c.Name,
+		Crisp:          c.Crisp,
+		Operators:      c.Operators.String(),
+		Implication:    c.Implication.String(),
+		Aggregation:    c.Aggregation.String(),
+		Resolution:     c.Resolution,
+		NoFire:         c.NoFire.String(),
+		Default:        c.Default,
+		PeakResolution: c.PeakResolution,
+		OutputGain:     c.OutputGain,
+	}
+	for name, s := range c.Scales {
+		if d.Scales == nil {
+			d.Scales = map[string]ScaleDef{}
+		}
+		d.Scales[name] = ScaleDef{Mode: s.Mode.String(), Ref: s.Ref, Factor: s.Factor}
+	}
+	if c.Defuzzifier != nil {
+		d.Defuzzifier = c.Defuzzifier.String()
+	}
+	for _, v := range c.Inputs {
+		vd, err := variableDef(v)
+		if err != nil {
+			return nil, err
+		}
+		d.Inputs = append(d.Inputs, vd)
+	}
+	out, err := variableDef(c.Output)
+	if err != nil {
+		return nil, err
+	}
+	d.Output = out
+	for _, r := range c.Rules {
+		rd := RuleDef{If: r.If.String(), Then: r.Then, TSK: r.TSK}
+		if r.Weight != 0 {
+			w := r.Weight
+			rd.Weight = &w
+		}
+		if r.Gain != 0 {
+			g := r.Gain
+			rd.Gain = &g
+		}
+		d.Rules = append(d.Rules, rd)
+	}
+	return d, nil
+}
+
+// ReadJSON reads and validates a controller definition in JSON.
+func ReadJSON(r io.Reader) (*Controller, error) {
+	var d Definition
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&d); err != nil {
+		return nil, fmt.Errorf("fuzzy: %w", err)
+	}
+	return d.Controller()
+}
+
+// ReadYAML reads and validates a controller definition in YAML.
+func ReadYAML(r io.Reader) (*Controller, error) {
+	var d Definition
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&d); err != nil {
+		return nil, fmt.Errorf("fuzzy: %w", err)
+	}
+	return d.Controller()
+}
+
+// WriteJSON writes the definition of c in JSON.
+func WriteJSON(w io.Writer, c *Controller) error {
+	d, err := NewDefinition(c)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
+// WriteYAML writes the definition of c in YAML.
+func WriteYAML(w io.Writer, c *Controller) error {
+	d, err := NewDefinition(c)
+	if err != nil {
+		return err
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(d); err != nil {
+		return err
+	}
+	return enc.Close()
+}
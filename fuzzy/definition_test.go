@@ -0,0 +1,166 @@
+package fuzzy
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+const testJSON = `{
+  "name": "test",
+  "inputs": [
+    {"name": "x", "range": [0, 1], "terms": [
+      {"name": "ALL", "type": "trapezoidal", "params": [-1, 0, 1, 2]}
+    ]}
+  ],
+  "output": {"name": "y", "range": [0, 10], "terms": [
+    {"name": "LOW", "type": "triangular", "params": [-1, 0, 1]},
+    {"name": "HIGH", "type": "triangular", "params": [9, 10, 11]}
+  ]},
+  "rules": [
+    {"if": "x IS ALL", "then": "LOW"},
+    {"if": "x IS ALL", "then": "HIGH", "weight": 0.5}
+  ],
+  "defuzzifier": "centroid",
+  "default": 0
+}`
+
+const testYAML = `name: test
+inputs:
+  - name: x
+    range: [0, 1]
+    terms:
+      - {name: ALL, type: trapezoidal, params: [-1, 0, 1, 2]}
+output:
+  name: y
+  range: [0, 10]
+  terms:
+    - {name: LOW, type: triangular, params: [-1, 0, 1]}
+    - {name: HIGH, type: triangular, params: [9, 10, 11]}
+rules:
+  - {if: x IS ALL, then: LOW}
+  - {if: x IS ALL, then: HIGH, weight: 0.5}
+defuzzifier: centroid
+default: 0
+`
+
+func TestReadDefinition(t *testing.T) {
+	for _, tc := range []struct {
+		format string
+		src    string
+		read   func(io.Reader) (*Controller, error)
+	}{
+		{"JSON", testJSON, ReadJSON},
+		{"YAML", testYAML, ReadYAML},
+	} {
+		c, err := tc.read(strings.NewReader(tc.src))
+		if err != nil {
+			t.Fatalf("%s: %v", tc.format, err)
+		}
+		if c.Name != "test" || len(c.Rules) != 2 || c.Rules[1].Weight != 0.5 || c.defuzzifier() != (Centroid{}) {
+			t.Errorf("%s: read %+v", tc.format, c)
+		}
+	}
+}
+
+func TestReadDefinitionErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name, old, new, want string
+	}{
+		{"unknown field", `"default": 0`, `"default": 0, "gain": 2`, `unknown field "gain"`},
+		{"syntax", `"rules": [`, `"rules": `, "invalid character"},
+		{"term type", `"type": "trapezoidal"`, `"type": "trapeze"`, `x: term ALL: unknown membership function type "trapeze"`},
+		{"arity", `[9, 10, 11]`, `[9, 10]`, "y: term HIGH: triangular needs 3 params, got 2"},
+		{"points", `"type": "triangular", "params": [-1, 0, 1]`, `"type": "points", "params": [0, 1, 1]`, "y: term LOW: points needs x y pairs, got 3 params"},
+		{"lower", `"params": [-1, 0, 1, 2]`, `"params": [-1, 0, 1, 2], "lower": [0, 1]`, "x: term ALL: lower: trapezoidal needs 4 params, got 2"},
+		{"antecedent", `"x IS ALL", "then": "LOW"`, `"x ALL", "then": "LOW"`, "fuzzy: rule 1:"},
+		{"defuzzifier", `"centroid"`, `"median"`, `unknown defuzzifier "median"`},
+		{"operators", `"default": 0`, `"default": 0, "operators": "yager"`, "yager"},
+		{"implication", `"default": 0`, `"default": 0, "implication": "sum"`, "sum"},
+		{"aggregation", `"default": 0`, `"default": 0, "aggregation": "min"`, "min"},
+		{"no-fire policy", `"default": 0`, `"default": 0, "noFire": "zero"`, `unknown no-fire policy "zero"`},
+		{"scale mode", `"default": 0`, `"default": 0, "scales": {"x": {"mode": "log"}}`, "log"},
+		{"invalid term", `[9, 10, 11]`, `[11, 10, 9]`, "y: term HIGH: triangular needs a <= b <= c"},
+		{"unknown output term", `"then": "HIGH"`, `"then": "MID"`, "rule 2: unknown term MID of y"},
+		{"weight", `"weight": 0.5`, `"weight": 1.5`, "rule 2: weight 1.5 is outside (0, 1]"},
+		{"weight 0", `"weight": 0.5`, `"weight": 0`, "rule 2: weight 0 never fires the rule"},
+		{"gain 0", `"weight": 0.5`, `"gain": 0`, "rule 2: gain 0 cancels the consequent"},
+		{"TSK coefficient", `"then": "LOW"`, `"tsk": {"const": 1, "coeffs": {"prefetch": 1}}`, "rule 1: TSK coefficient of unknown input prefetch"},
+		{"TSK defuzzifier", `"then": "LOW"`, `"tsk": {"const": 1}`, "TSK rules need the weighted-average defuzzifier, not centroid"},
+		{"type-2 defuzzifier", `"params": [-1, 0, 1, 2]`, `"params": [-1, 0, 1, 2], "lower": [-0.5, 0.5, 0.5, 1.5]`, "type-2 terms need the weighted-average defuzzifier, not centroid"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if !strings.Contains(testJSON, tc.old) {
+				t.Fatalf("%q is not in the test definition", tc.old)
+			}
+			_, err := ReadJSON(strings.NewReader(strings.Replace(testJSON, tc.old, tc.new, 1)))
+			if err == nil {
+				t.Fatalf("ReadJSON accepted the definition, want %q", tc.want)
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("ReadJSON = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestReadYAMLErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name, old, new, want string
+	}{
+		{"unknown field", "default: 0", "default: 0\ngain: 2", "field gain not found"},
+		{"term type", "type: trapezoidal", "type: trapeze", `x: term ALL: unknown membership function type "trapeze"`},
+		{"unknown output term", "then: HIGH", "then: MID", "rule 2: unknown term MID of y"},
+		{"weight 0", "weight: 0.5", "weight: 0", "rule 2: weight 0 never fires the rule"},
+		{"TSK coefficient", "then: LOW", "tsk: {coeffs: {x: 1, prefetch: 1}}", "rule 1: TSK coefficient of unknown input prefetch"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ReadYAML(strings.NewReader(strings.Replace(testYAML, tc.old, tc.new, 1)))
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("ReadYAML = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+// TestDefinitionRoundTrip checks that WriteJSON and WriteYAML keep every
+// setting ReadJSON and ReadYAML read back, crisp inputs included.
+func TestDefinitionRoundTrip(t *testing.T) {
+	c := weightController(0.5, 2)
+	c.Rules[0].TSK = &Linear{Const: 1, Coeffs: map[string]float64{"x": 2, "prefetch": 0.5}}
+	c.Crisp = []string{"prefetch"}
+	c.Scales = map[string]InputScale{"x": {Mode: ScaleUnit, Ref: "goal"}}
+	c.OutputGain, c.NoFire, c.Default = 1.5, NoFireError, 3
+	in := map[string]float64{"x": 30, "goal": 100, "prefetch": 20}
+	want, err := c.Evaluate(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		format string
+		write  func(io.Writer, *Controller) error
+		read   func(io.Reader) (*Controller, error)
+	}{
+		{"JSON", WriteJSON, ReadJSON},
+		{"YAML", WriteYAML, ReadYAML},
+	} {
+		var buf bytes.Buffer
+		if err := tc.write(&buf, c); err != nil {
+			t.Fatal(err)
+		}
+		src := buf.String()
+		back, err := tc.read(&buf)
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", tc.format, err, src)
+		}
+		got, err := back.Evaluate(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want || back.NoFire != c.NoFire || back.Default != c.Default || back.Rules[1].Gain != 2 {
+			t.Errorf("%s: output %g after the round trip, want %g\n%s", tc.format, got, want, src)
+		}
+	}
+}
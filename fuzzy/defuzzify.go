@@ -0,0 +1,169 @@
+package fuzzy
+
+import (
+	"fmt"
+	"math"
+)
+
+// maxTolerance is how far below the maximum of the output set a sample may
+// be and still count as part of its plateau.
+const maxTolerance = 1e-9
+
+// Defuzzifier turns fired rules into a crisp output. It reports false when
+// no rule fired, leaving the decision to the controller's NoFirePolicy.
+type Defuzzifier interface {
+	Defuzzify(o *Output) (float64, bool)
+	String() string
+}
+
+// ParseDefuzzifier returns the defuzzifier called name, as printed by its
+// String method.
+func ParseDefuzzifier(name string) (Defuzzifier, error) {
+	for _, d := range []Defuzzifier{
+		WeightedAverage{}, Centroid{}, Bisector{},
+		MeanOfMaximum{}, SmallestOfMaximum{}, LargestOfMaximum{},
+	} {
+		if d.String() == name {
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf("fuzzy: unknown defuzzifier %q", name)
+}
+
+// WeightedAverage averages the representative value of every consequent,
+// scaled by the rule gain, weighted by the rule firing strength times the
+// rule weight. It does not build the output set at all.
+//
+// With interval type-2 terms it is the centre of sets type reduction: the
+// Karnik-Mendel procedure finds the smallest and the largest average the
+// firing intervals allow, and the output is the middle of the two.
+type WeightedAverage struct{}
+
+func (WeightedAverage) String() string { return "weighted-average" }
+
+func (WeightedAverage) Defuzzify(o *Output) (float64, bool) {
+	if o.type2 {
+		return o.typeReduce()
+	}
+	numerator, denominator := 0.0, 0.0
+	for _, a := range o.Activations {
+		w := a.Strength * a.Rule.weight()
+		numerator += w * a.Value
+		denominator += w
+	}
+	if denominator == 0 {
+		return 0, false
+	}
+	return numerator / denominator, true
+}
+
+// Centroid returns the centre of gravity of the aggregated output set.
+type Centroid struct{}
+
+func (Centroid) String() string { return "centroid" }
+
+func (Centroid) Defuzzify(o *Output) (float64, bool) {
+	xs, mus := o.Surface()
+	numerator, denominator := 0.0, 0.0
+	for i, x := range xs {
+		numerator += x * mus[i]
+		denominator += mus[i]
+	}
+	if denominator == 0 {
+		return 0, false
+	}
+	return numerator / denominator, true
+}
+
+// Bisector returns the point that splits the area of the aggregated output
+// set in two equal halves.
+type Bisector struct{}
+
+func (Bisector) String() string { return "bisector" }
+
+func (Bisector) Defuzzify(o *Output) (float64, bool) {
+	xs, mus := o.Surface()
+	total := 0.0
+	for _, mu := range mus {
+		total += mu
+	}
+	if total == 0 {
+		return 0, false
+	}
+
+	acc := 0.0
+	for i, mu := range mus {
+		acc += mu
+		if acc >= total/2 {
+			return xs[i], true
+		}
+	}
+	return xs[len(xs)-1], true
+}
+
+// maxima returns the samples where the aggregated output set reaches its
+// highest membership.
+func maxima(o *Output) []float64 {
+	xs, mus := o.Surface()
+	max := 0.0
+	for _, mu := range mus {
+		max = math.Max(max, mu)
+	}
+	if max == 0 {
+		return nil
+	}
+
+	var at []float64
+	for i, mu := range mus {
+		if mu >= max-maxTolerance {
+			at = append(at, xs[i])
+		}
+	}
+	return at
+}
+
+// MeanOfMaximum returns the mean of the points where the aggregated output
+// set is highest.
+type MeanOfMaximum struct{}
+
+func (MeanOfMaximum) String() string { return "mom" }
+
+func (MeanOfMaximum) Defuzzify(o *Output) (float64, bool) {
+	at := maxima(o)
+	if len(at) == 0 {
+		return 0, false
+	}
+	sum := 0.0
+	for _, x := range at {
+		sum += x
+	}
+	return sum / float64(len(at)), true
+}
+
+// SmallestOfMaximum returns the smallest point where the aggregated output
+// set is highest.
+type SmallestOfMaximum struct{}
+
+func (SmallestOfMaximum) String() string { return "som" }
+
+func (SmallestOfMaximum) Defuzzify(o *Output) (float64, bool) {
+	at := maxima(o)
+	if len(at) == 0 {
+		return 0, false
+	}
+	return at[0], true
+}
+
+// LargestOfMaximum returns the largest point where the aggregated output set
+// is highest.
+type LargestOfMaximum struct{}
+
+func (LargestOfMaximum) String() string { return "lom" }
+
+func (LargestOfMaximum) Defuzzify(o *Output) (float64, bool) {
+	at := maxima(o)
+	if len(at) == 0 {
+		return 0, false
+	}
+	return at[len(at)-1], true
+}
@@ -0,0 +1,87 @@
+package fuzzy
+
+import (
+	"math"
+	"testing"
+)
+
+// weightController fires two rules fully for any input in [0, 1]: one
+// concluding on a term peaking at 0 and one on a term peaking at 10.
+func weightController(weight, gain float64) *Controller {
+	return &Controller{
+		Inputs: []*Variable{{Name: "x", Min: 0, Max: 1, Terms: []Term{
+			{Name: "ALL", MF: Trapezoidal{-1, 0, 1, 2}},
+		}}},
+		Output: &Variable{Name: "y", Min: 0, Max: 10, Terms: []Term{
+			{Name: "LOW", MF: Triangular{-1, 0, 1}},
+			{Name: "HIGH", MF: Triangular{9, 10, 11}},
+		}},
+		Rules: []Rule{
+			{If: Is{Var: "x", Term: "ALL"}, Then: "LOW"},
+			{If: Is{Var: "x", Term: "ALL"}, Then: "HIGH", Weight: weight, Gain: gain},
+		},
+		Resolution: 101,
+	}
+}
+
+// TestRuleWeightScalesStrength pins the meaning of rule weights: they scale
+// the firing strength, in the weighted average as in the aggregated output
+// set, so the output stays between the consequent peaks.
+func TestRuleWeightScalesStrength(t *testing.T) {
+	c := weightController(0.5, 0)
+	u, err := c.Evaluate(map[string]float64{"x": 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 10.0 / 3; math.Abs(u-want) > 1e-9 {
+		t.Errorf("weighted average = %g, want (1*0 + 0.5*10) / (1 + 0.5) = %g", u, want)
+	}
+
+	out, err := c.Fire(map[string]float64{"x": 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	xs, mus := out.Surface()
+	if xs[100] != 10 || math.Abs(mus[100]-0.5) > 1e-9 {
+		t.Errorf("aggregated set at %g = %g, want the weight 0.5", xs[100], mus[100])
+	}
+	if math.Abs(mus[0]-1) > 1e-9 {
+		t.Errorf("aggregated set at %g = %g, want 1", xs[0], mus[0])
+	}
+
+	for _, w := range []float64{-0.5, 1.5, math.NaN()} {
+		if err := weightController(w, 0).Validate(); err == nil {
+			t.Errorf("Validate accepted weight %g", w)
+		}
+	}
+}
+
+// TestRuleGainScalesConsequent pins the meaning of rule gains: they scale
+// the consequent, its peak in the weighted average and its term, stretched
+// about zero, in the aggregated output set, and leave the strength alone.
+func TestRuleGainScalesConsequent(t *testing.T) {
+	c := weightController(0, 0.5)
+	u, err := c.Evaluate(map[string]float64{"x": 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 2.5; math.Abs(u-want) > 1e-9 {
+		t.Errorf("weighted average = %g, want (0 + 0.5*10) / 2 = %g", u, want)
+	}
+
+	out, err := c.Fire(map[string]float64{"x": 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	xs, mus := out.Surface()
+	if math.Abs(xs[50]-5) > 1e-9 || math.Abs(mus[50]-1) > 1e-9 {
+		t.Errorf("aggregated set at %g = %g, want the stretched peak 1", xs[50], mus[50])
+	}
+	if mus[100] != 0 {
+		t.Errorf("aggregated set at %g = %g, want 0", xs[100], mus[100])
+	}
+
+	if err := weightController(0, -1).Validate(); err == nil {
+		t.Error("Validate accepted a negative gain")
+	}
+}
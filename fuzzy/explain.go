@@ -0,0 +1,96 @@
+package fuzzy
+
+// Explanation records how a controller reached one crisp output, for
+// auditing its decisions. It marshals to JSON as is.
+type Explanation struct {
+	Controller string             `json:"controller,omitempty"`
+	Inputs     map[string]float64 `json:"inputs"`
+	// Scaled are the inputs after the controller Scales, when it has any.
+	Scaled map[string]float64 `json:"scaled,omitempty"`
+	// Memberships are the degrees of every term of every input, and for
+	// controllers with interval type-2 terms LowerMemberships the degrees
+	// of their lower membership functions.
+	Memberships      Memberships `json:"memberships"`
+	LowerMemberships Memberships `json:"lowerMemberships,omitempty"`
+	Rules            []RuleTrace `json:"rules"`
+	// Aggregated is the output set the rules imply, sampled at the
+	// controller Resolution. It is left out when rules have TSK
+	// consequents, which have no output set.
+	Aggregated  *OutputSet `json:"aggregated,omitempty"`
+	Defuzzifier string     `json:"defuzzifier"`
+	// Interval is the type-reduced output of a type-2 controller, before
+	// the output gain: Output lies in its middle.
+	Interval []float64 `json:"interval,omitempty"`
+	// Fired is false when no rule fired and Output comes from the
+	// controller NoFire policy, named in NoFire.
+	Fired  bool    `json:"fired"`
+	NoFire string  `json:"noFire,omitempty"`
+	Output float64 `json:"output"`
+}
+
+// RuleTrace is how one rule took part in an evaluation.
+type RuleTrace struct {
+	Rule     string  `json:"rule"`
+	Strength float64 `json:"strength"`
+	// Lower is the lower firing strength of the rule in a type-2
+	// controller, whose firing strength is the interval [Lower, Strength].
+	Lower float64 `json:"lower,omitempty"`
+	// Weight is the weight of the firing strength of the rule and Gain the
+	// gain of its consequent, 1 when unset.
+	Weight float64 `json:"weight"`
+	Gain   float64 `json:"gain"`
+	// Value is the representative value of the consequent, with the gain
+	// applied: the peak of the output term, or the value of the TSK
+	// consequent.
+	Value float64 `json:"value"`
+}
+
+// OutputSet is a sampled fuzzy set of the output universe.
+type OutputSet struct {
+	X  []float64 `json:"x"`
+	Mu []float64 `json:"mu"`
+}
+
+// Explain evaluates the controller like Evaluate and also returns the
+// explanation of the result. The explanation is returned even when the
+// evaluation fails after firing the rules, e.g. with ErrNoRuleFired.
+func (c *Controller) Explain(in map[string]float64) (float64, *Explanation, error) {
+	u, out, fired, err := c.evaluate(in)
+	if out == nil {
+		return u, nil, err
+	}
+
+	x := &Explanation{
+		Controller:  c.Name,
+		Inputs:      in,
+		Rules:       make([]RuleTrace, len(out.Activations)),
+		Defuzzifier: c.defuzzifier().String(),
+		Fired:       fired,
+		Output:      u,
+	}
+	// Fire already fuzzified the scaled inputs successfully.
+	x.Memberships, _ = c.Fuzzify(out.in)
+	if len(c.Scales) > 0 {
+		x.Scaled = out.in
+	}
+	for i, a := range out.Activations {
+		x.Rules[i] = RuleTrace{Rule: a.Rule.String(), Strength: a.Strength, Weight: a.Rule.weight(), Gain: a.Rule.gain(), Value: a.Value}
+		if out.type2 {
+			x.Rules[i].Lower = a.Lower
+		}
+	}
+	if out.type2 {
+		x.LowerMemberships = c.fuzzifyLower(out.in)
+		if fired {
+			x.Interval = []float64{out.yl, out.yr}
+		}
+	}
+	if !out.tsk {
+		xs, mus := out.Surface()
+		x.Aggregated = &OutputSet{X: xs, Mu: mus}
+	}
+	if !fired {
+		x.NoFire = c.NoFire.String()
+	}
+	return u, x, err
+}
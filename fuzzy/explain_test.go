@@ -0,0 +1,76 @@
+package fuzzy
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// TestExplain checks that the trace of every rule holds the membership of
+// its term at the input, that the average of the traced values weighted by
+// strength and rule weight is the weighted-average output, and that the output is the one Evaluate returns.
+func TestExplain(t *testing.T) {
+	weighted := benchController()
+	weighted.Rules[4].Weight, weighted.Rules[4].Gain = 0.5, 2
+	centroid := benchController()
+	centroid.Defuzzifier = Centroid{}
+
+	for _, c := range []*Controller{weighted, centroid} {
+		for e := -20000.0; e <= 20000; e += 1250 {
+			in := map[string]float64{"error": e}
+			want, err := c.Evaluate(in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			u, x, err := c.Explain(in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if u != want || x.Output != want || !x.Fired {
+				t.Errorf("%s, error %g: explained output %g (%g, fired %t), Evaluate gives %g",
+					c.defuzzifier(), e, u, x.Output, x.Fired, want)
+			}
+			if x.Defuzzifier != c.defuzzifier().String() || x.Aggregated == nil {
+				t.Errorf("%s, error %g: defuzzifier %q, aggregated set %v", c.defuzzifier(), e, x.Defuzzifier, x.Aggregated)
+			}
+
+			var sum, weights float64
+			for i, r := range c.Rules {
+				term, _ := c.Inputs[0].Term(r.If.(Is).Term)
+				mu := term.MF.Eval(e)
+				if x.Memberships["error"][term.Name] != mu {
+					t.Errorf("error %g: membership of %s = %g, want %g", e, term.Name, x.Memberships["error"][term.Name], mu)
+				}
+				trace := x.Rules[i]
+				if math.Abs(trace.Strength-mu) > 1e-12 {
+					t.Errorf("error %g: strength of %s = %g, want %g", e, trace.Rule, trace.Strength, mu)
+				}
+				if trace.Weight != r.weight() || trace.Gain != r.gain() {
+					t.Errorf("error %g: %s traced with weight %g and gain %g", e, trace.Rule, trace.Weight, trace.Gain)
+				}
+				sum += trace.Strength * trace.Weight * trace.Value
+				weights += trace.Strength * trace.Weight
+			}
+			if c == weighted && math.Abs(sum/weights-want) > 1e-9 {
+				t.Errorf("error %g: weighted average of the traces = %g, want %g", e, sum/weights, want)
+			}
+		}
+	}
+}
+
+// TestExplainNoFire checks that the explanation of an input no rule covers
+// names the no-fire policy and keeps the output and error of Evaluate.
+func TestExplainNoFire(t *testing.T) {
+	c := weightController(0, 0)
+	c.Inputs[0].Terms[0].MF = Triangular{0, 0.5, 1}
+	c.NoFire = NoFireError
+	in := map[string]float64{"x": 1}
+	_, wantErr := c.Evaluate(in)
+	u, x, err := c.Explain(in)
+	if !errors.Is(err, ErrNoRuleFired) || !errors.Is(wantErr, ErrNoRuleFired) {
+		t.Fatalf("Explain = %v, Evaluate = %v, want %v", err, wantErr, ErrNoRuleFired)
+	}
+	if x == nil || x.Fired || x.NoFire != NoFireError.String() || u != 0 {
+		t.Errorf("explanation %+v of output %g", x, u)
+	}
+}
@@ -0,0 +1,960 @@
+package fuzzy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// fclMethods maps the FCL defuzzification methods to their defuzzifiers.
+// COGS, the centre of gravity of singletons, stands for the weighted average
+// of consequent peaks: the two agree for singleton output terms, while other
+// tools may take the centre of gravity of other shapes instead of the peak.
+var fclMethods = map[string]Defuzzifier{
+	"COG":  Centroid{},
+	"COGS": WeightedAverage{},
+	"COA":  Bisector{},
+	"MM":   MeanOfMaximum{},
+	"LM":   SmallestOfMaximum{},
+	"RM":   LargestOfMaximum{},
+}
+
+// fclOperators maps the FCL AND and OR methods to their operator families.
+// HAMACHER, EINSTEIN, DMIN and DMAX are not part of the standard; they are
+// named as in jFuzzyLogic.
+var fclOperators = map[string]map[string]Operators{
+	"AND": {"MIN": MinMax, "PROD": Product, "BDIF": Lukasiewicz, "HAMACHER": Hamacher, "EINSTEIN": Einstein, "DMIN": Drastic},
+	"OR":  {"MAX": MinMax, "ASUM": Product, "BSUM": Lukasiewicz, "HAMACHER": Hamacher, "EINSTEIN": Einstein, "DMAX": Drastic},
+}
+
+func fclOperatorName(op string, ops Operators) string {
+	for m, o := range fclOperators[op] {
+		if o == ops {
+			return m
+		}
+	}
+	return ""
+}
+
+// ReadFCL reads and validates a controller from an IEC 61131-7 Fuzzy Control
+// Language function block. Besides point lists and singletons, terms may use the
+// function shapes written by WriteFCL and by jFuzzyLogic: trian, trape,
+// gauss, gbell, sigm, dsigm, pi, smf and zmf.
+func ReadFCL(r io.Reader) (*Controller, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	toks, err := fclTokenize(string(src))
+	if err != nil {
+		return nil, err
+	}
+	p := &fclParser{toks: toks}
+	c, err := p.functionBlock()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+type fclToken struct {
+	text string
+	line int
+}
+
+func fclTokenize(src string) ([]fclToken, error) {
+	var toks []fclToken
+	line := 1
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case unicode.IsSpace(rune(c)):
+			i++
+		case strings.HasPrefix(src[i:], "(*"):
+			end := strings.Index(src[i:], "*)")
+			if end < 0 {
+				return nil, fmt.Errorf("fcl: line %d: unterminated comment", line)
+			}
+			line += strings.Count(src[i:i+end], "\n")
+			i += end + 2
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], ":="), strings.HasPrefix(src[i:], ".."):
+			toks = append(toks, fclToken{src[i : i+2], line})
+			i += 2
+		case strings.ContainsRune("();:,", rune(c)):
+			toks = append(toks, fclToken{src[i : i+1], line})
+			i++
+		case c == '-' || c == '+' || c == '.' || unicode.IsDigit(rune(c)):
+			j := i + 1
+			for j < len(src) && (unicode.IsDigit(rune(src[j])) ||
+				src[j] == '.' && !strings.HasPrefix(src[j:], "..") ||
+				src[j] == 'e' || src[j] == 'E' ||
+				(src[j] == '-' || src[j] == '+') && (src[j-1] == 'e' || src[j-1] == 'E')) {
+				j++
+			}
+			toks = append(toks, fclToken{src[i:j], line})
+			i = j
+		case c == '_' || unicode.IsLetter(rune(c)):
+			j := i + 1
+			for j < len(src) && (src[j] == '_' || unicode.IsLetter(rune(src[j])) || unicode.IsDigit(rune(src[j]))) {
+				j++
+			}
+			toks = append(toks, fclToken{src[i:j], line})
+			i = j
+		default:
+			return nil, fmt.Errorf("fcl: line %d: unexpected %q", line, c)
+		}
+	}
+	return toks, nil
+}
+
+type fclParser struct {
+	toks []fclToken
+	pos  int
+}
+
+func (p *fclParser) errorf(format string, args ...interface{}) error {
+	line := 0
+	if p.pos < len(p.toks) {
+		line = p.toks[p.pos].line
+	} else if len(p.toks) > 0 {
+		line = p.toks[len(p.toks)-1].line
+	}
+	return fmt.Errorf("fcl: line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+func (p *fclParser) peek() string {
+	if p.pos >= len(p.toks) {
+		return ""
+	}
+	return p.toks[p.pos].text
+}
+
+// is reports whether the next token is the keyword kw.
+func (p *fclParser) is(kw string) bool {
+	return strings.EqualFold(p.peek(), kw)
+}
+
+func (p *fclParser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *fclParser) expect(kw string) error {
+	if !p.is(kw) {
+		if p.peek() == "" {
+			return p.errorf("expected %s, got end of file", kw)
+		}
+		return p.errorf("expected %s, got %q", kw, p.peek())
+	}
+	p.pos++
+	return nil
+}
+
+func (p *fclParser) ident() (string, error) {
+	t := p.peek()
+	if t == "" || !(t[0] == '_' || unicode.IsLetter(rune(t[0]))) {
+		return "", p.errorf("expected a name, got %q", t)
+	}
+	p.pos++
+	return t, nil
+}
+
+func (p *fclParser) number() (float64, error) {
+	t := p.peek()
+	x, err := strconv.ParseFloat(t, 64)
+	if err != nil {
+		return 0, p.errorf("expected a number, got %q", t)
+	}
+	p.pos++
+	return x, nil
+}
+
+func (p *fclParser) numbers(n int) ([]float64, error) {
+	xs := make([]float64, n)
+	for i := range xs {
+		x, err := p.number()
+		if err != nil {
+			return nil, err
+		}
+		xs[i] = x
+	}
+	return xs, nil
+}
+
+func (p *fclParser) functionBlock() (*Controller, error) {
+	if err := p.expect("FUNCTION_BLOCK"); err != nil {
+		return nil, err
+	}
+	c := &Controller{}
+	if !p.is("VAR_INPUT") && !p.is("VAR_OUTPUT") && !p.is("FUZZIFY") {
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		c.Name = name
+	}
+
+	for !p.is("END_FUNCTION_BLOCK") {
+		var err error
+		switch {
+		case p.is("VAR_INPUT"), p.is("VAR_OUTPUT"):
+			err = p.varBlock()
+		case p.is("FUZZIFY"):
+			var v *Variable
+			if v, err = p.fuzzify(); err == nil {
+				c.Inputs = append(c.Inputs, v)
+			}
+		case p.is("DEFUZZIFY"):
+			if c.Output != nil {
+				return nil, p.errorf("only one output variable is supported")
+			}
+			err = p.defuzzify(c)
+		case p.is("RULEBLOCK"):
+			err = p.ruleBlock(c)
+		case p.peek() == "":
+			return nil, p.errorf("expected END_FUNCTION_BLOCK, got end of file")
+		default:
+			return nil, p.errorf("unexpected %q", p.peek())
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if c.Output == nil {
+		return nil, p.errorf("missing DEFUZZIFY block")
+	}
+	return c, nil
+}
+
+// varBlock skips a VAR_INPUT or VAR_OUTPUT declaration: the variables are
+// defined by their FUZZIFY and DEFUZZIFY blocks.
+func (p *fclParser) varBlock() error {
+	p.next()
+	for !p.is("END_VAR") {
+		if _, err := p.ident(); err != nil {
+			return err
+		}
+		if err := p.expect(":"); err != nil {
+			return err
+		}
+		if _, err := p.ident(); err != nil {
+			return err
+		}
+		if err := p.expect(";"); err != nil {
+			return err
+		}
+	}
+	p.next()
+	return nil
+}
+
+func (p *fclParser) fuzzify() (*Variable, error) {
+	p.next()
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	v := &Variable{Name: name}
+	hasRange := false
+	for !p.is("END_FUZZIFY") {
+		switch {
+		case p.is("TERM"):
+			err = p.term(v)
+		case p.is("RANGE"):
+			err = p.rangeDef(v)
+			hasRange = true
+		default:
+			return nil, p.errorf("unexpected %q in FUZZIFY %s", p.peek(), name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	p.next()
+	if !hasRange {
+		v.fitUniverse()
+	}
+	return v, nil
+}
+
+func (p *fclParser) defuzzify(c *Controller) error {
+	p.next()
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	v := &Variable{Name: name}
+	hasRange := false
+	for !p.is("END_DEFUZZIFY") {
+		switch {
+		case p.is("TERM"):
+			err = p.term(v)
+		case p.is("RANGE"):
+			err = p.rangeDef(v)
+			hasRange = true
+		case p.is("METHOD"):
+			p.next()
+			if err = p.expect(":"); err != nil {
+				return err
+			}
+			m := strings.ToUpper(p.next())
+			d, ok := fclMethods[m]
+			if !ok {
+				return p.errorf("unsupported METHOD %s", m)
+			}
+			c.Defuzzifier = d
+			err = p.expect(";")
+		case p.is("DEFAULT"):
+			p.next()
+			if err = p.expect(":="); err != nil {
+				return err
+			}
+			if p.is("NC") {
+				p.next()
+				c.NoFire = NoFireHold
+			} else {
+				c.NoFire = NoFireDefault
+				c.Default, err = p.number()
+			}
+			if err == nil {
+				err = p.expect(";")
+			}
+		case p.is("ACCU"):
+			err = p.accu(c)
+		default:
+			return p.errorf("unexpected %q in DEFUZZIFY %s", p.peek(), name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	p.next()
+	if !hasRange {
+		v.fitUniverse()
+	}
+	c.Output = v
+	return nil
+}
+
+func (p *fclParser) rangeDef(v *Variable) error {
+	p.next()
+	if err := p.expect(":="); err != nil {
+		return err
+	}
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	min, err := p.number()
+	if err != nil {
+		return err
+	}
+	if err := p.expect(".."); err != nil {
+		return err
+	}
+	max, err := p.number()
+	if err != nil {
+		return err
+	}
+	if err := p.expect(")"); err != nil {
+		return err
+	}
+	v.Min, v.Max = min, max
+	return p.expect(";")
+}
+
+func (p *fclParser) term(v *Variable) error {
+	p.next()
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if err := p.expect(":="); err != nil {
+		return err
+	}
+	mf, err := p.membership()
+	if err != nil {
+		return err
+	}
+	v.Terms = append(v.Terms, Term{Name: name, MF: mf})
+	return p.expect(";")
+}
+
+func (p *fclParser) membership() (MembershipFunction, error) {
+	if p.peek() == "(" {
+		var pts PiecewiseLinear
+		for p.peek() == "(" {
+			p.next()
+			xy := make([]float64, 2)
+			var err error
+			if xy[0], err = p.number(); err != nil {
+				return nil, err
+			}
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+			if xy[1], err = p.number(); err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			pts = append(pts, Point{xy[0], xy[1]})
+		}
+		return pts, nil
+	}
+	if x, err := strconv.ParseFloat(p.peek(), 64); err == nil {
+		p.next()
+		return Singleton{X: x}, nil
+	}
+
+	shape := strings.ToLower(p.next())
+	arity := map[string]int{
+		"trian": 3, "trape": 4, "gauss": 2, "gbell": 3, "sigm": 2,
+		"dsigm": 4, "pi": 4, "smf": 2, "zmf": 2, "singleton": 1,
+	}
+	n, ok := arity[shape]
+	if !ok {
+		p.pos--
+		return nil, p.errorf("unknown membership function %q", shape)
+	}
+	a, err := p.numbers(n)
+	if err != nil {
+		return nil, err
+	}
+	switch shape {
+	case "trian":
+		return Triangular{a[0], a[1], a[2]}, nil
+	case "trape":
+		return Trapezoidal{a[0], a[1], a[2], a[3]}, nil
+	case "gauss":
+		return Gaussian{a[0], a[1]}, nil
+	case "gbell":
+		return Bell{a[0], a[1], a[2]}, nil
+	case "sigm":
+		return Sigmoid{a[0], a[1]}, nil
+	case "dsigm":
+		return DiffSigmoid{a[0], a[1], a[2], a[3]}, nil
+	case "pi":
+		return Pi{a[0], a[1], a[2], a[3]}, nil
+	case "smf":
+		return SShape{a[0], a[1]}, nil
+	case "zmf":
+		return ZShape{a[0], a[1]}, nil
+	default:
+		return Singleton{a[0]}, nil
+	}
+}
+
+func (p *fclParser) accu(c *Controller) error {
+	p.next()
+	if err := p.expect(":"); err != nil {
+		return err
+	}
+	switch m := strings.ToUpper(p.next()); m {
+	case "MAX":
+		c.Aggregation = AggregateMax
+	case "SUM", "BSUM", "NSUM":
+		c.Aggregation = AggregateSum
+	default:
+		return p.errorf("unsupported ACCU %s", m)
+	}
+	return p.expect(";")
+}
+
+func (p *fclParser) ruleBlock(c *Controller) error {
+	p.next()
+	if !p.is("AND") && !p.is("OR") && !p.is("ACT") && !p.is("ACCU") && !p.is("RULE") {
+		if _, err := p.ident(); err != nil {
+			return err
+		}
+	}
+	// AND and OR must pick operators of the same family.
+	opsSet := map[string]Operators{}
+	other := map[string]string{"AND": "OR", "OR": "AND"}
+	for !p.is("END_RULEBLOCK") {
+		var err error
+		switch {
+		case p.is("AND"), p.is("OR"):
+			op := strings.ToUpper(p.next())
+			if err = p.expect(":"); err != nil {
+				return err
+			}
+			m := strings.ToUpper(p.next())
+			ops, ok := fclOperators[op][m]
+			if !ok {
+				return p.errorf("unsupported %s operator %s", op, m)
+			}
+			if set, ok := opsSet[op]; ok && set != ops {
+				return p.errorf("%s : %s and %s : %s are not of the same family", other[op], fclOperatorName(other[op], set), op, m)
+			}
+			c.Operators = ops
+			opsSet[other[op]] = ops
+			err = p.expect(";")
+		case p.is("ACT"):
+			p.next()
+			if err = p.expect(":"); err != nil {
+				return err
+			}
+			switch m := strings.ToUpper(p.next()); m {
+			case "MIN":
+				c.Implication = Clip
+			case "PROD":
+				c.Implication = Scale
+			default:
+				return p.errorf("unsupported ACT %s", m)
+			}
+			err = p.expect(";")
+		case p.is("ACCU"):
+			err = p.accu(c)
+		case p.is("RULE"):
+			err = p.rule(c)
+		default:
+			return p.errorf("unexpected %q in RULEBLOCK", p.peek())
+		}
+		if err != nil {
+			return err
+		}
+	}
+	p.next()
+	return nil
+}
+
+func (p *fclParser) rule(c *Controller) error {
+	p.next()
+	p.next() // rule number
+	if err := p.expect(":"); err != nil {
+		return err
+	}
+	if err := p.expect("IF"); err != nil {
+		return err
+	}
+	cond, err := p.antecedent()
+	if err != nil {
+		return err
+	}
+	for _, is := range clauses(cond) {
+		v := c.input(is.Var)
+		if v == nil {
+			return p.errorf("unknown input variable %s", is.Var)
+		}
+		if _, ok := v.Term(is.Term); !ok {
+			return p.errorf("unknown term %s of %s", is.Term, is.Var)
+		}
+	}
+	if err := p.expect("THEN"); err != nil {
+		return err
+	}
+	out, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if c.Output == nil || out != c.Output.Name {
+		return p.errorf("rule concludes on %s, which is not the output variable", out)
+	}
+	if err := p.expect("IS"); err != nil {
+		return err
+	}
+	term, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if _, ok := c.Output.Term(term); !ok {
+		return p.errorf("unknown term %s of %s", term, out)
+	}
+
+	r := Rule{If: cond, Then: term}
+	if p.is("WITH") {
+		p.next()
+		if r.Weight, err = p.number(); err != nil {
+			return err
+		}
+		if r.Weight == 0 {
+			return p.errorf("WITH 0 never fires the rule, remove the rule instead")
+		}
+	}
+	c.Rules = append(c.Rules, r)
+	return p.expect(";")
+}
+
+// antecedent parses a disjunction of conjunctions of clauses; NOT binds
+// tighter than AND, which binds tighter than OR.
+func (p *fclParser) antecedent() (Antecedent, error) {
+	var or Or
+	for {
+		a, err := p.conjunction()
+		if err != nil {
+			return nil, err
+		}
+		or = append(or, a)
+		if !p.is("OR") {
+			break
+		}
+		p.next()
+	}
+	if len(or) == 1 {
+		return or[0], nil
+	}
+	return or, nil
+}
+
+func (p *fclParser) conjunction() (Antecedent, error) {
+	var and And
+	for {
+		a, err := p.clause()
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, a)
+		if !p.is("AND") {
+			break
+		}
+		p.next()
+	}
+	if len(and) == 1 {
+		return and[0], nil
+	}
+	return and, nil
+}
+
+func (p *fclParser) clause() (Antecedent, error) {
+	switch {
+	case p.is("NOT"):
+		p.next()
+		a, err := p.clause()
+		if err != nil {
+			return nil, err
+		}
+		return Not{a}, nil
+	case p.is("("):
+		p.next()
+		a, err := p.antecedent()
+		if err != nil {
+			return nil, err
+		}
+		return a, p.expect(")")
+	}
+
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("IS"); err != nil {
+		return nil, err
+	}
+	is := Is{Var: name}
+	for p.hedge() {
+		h, _ := parseHedge(p.next())
+		is.Hedges = append(is.Hedges, h)
+	}
+	if is.Term, err = p.ident(); err != nil {
+		return nil, err
+	}
+	return is, nil
+}
+
+// hedge reports whether the next token is a hedge rather than a term: a
+// hedge name followed by another name.
+func (p *fclParser) hedge() bool {
+	if _, ok := parseHedge(p.peek()); !ok || p.pos+1 >= len(p.toks) {
+		return false
+	}
+	after := p.toks[p.pos+1].text
+	for _, kw := range []string{"AND", "OR", "THEN"} {
+		if strings.EqualFold(after, kw) {
+			return false
+		}
+	}
+	return after[0] == '_' || unicode.IsLetter(rune(after[0]))
+}
+
+// ParseAntecedent parses the IF part of a rule in FCL syntax, e.g.
+// "error IS very LP AND (delta_error IS ZE OR NOT delta_error IS SN)".
+// Clauses may have the hedges very, somewhat, extremely, slightly and not.
+func ParseAntecedent(s string) (Antecedent, error) {
+	toks, err := fclTokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &fclParser{toks: toks}
+	a, err := p.antecedent()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != "" {
+		return nil, p.errorf("unexpected %q after antecedent", p.peek())
+	}
+	return a, nil
+}
+
+// input returns the input variable called name, or nil.
+func (c *Controller) input(name string) *Variable {
+	for _, v := range c.Inputs {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// fitUniverse sets the universe of v to the span of its terms, for variables
+// defined without a RANGE.
+func (v *Variable) fitUniverse() {
+	v.Min, v.Max = math.Inf(1), math.Inf(-1)
+	for _, t := range v.Terms {
+		lo, hi := t.MF.Support()
+		peak := t.MF.Peak()
+		for _, x := range []float64{lo, hi, peak} {
+			if !math.IsInf(x, 0) {
+				v.Min, v.Max = math.Min(v.Min, x), math.Max(v.Max, x)
+			}
+		}
+	}
+	if v.Min > v.Max {
+		v.Min, v.Max = 0, 0
+	}
+}
+
+// WriteFCL writes c as an IEC 61131-7 Fuzzy Control Language function block
+// that ReadFCL reads back. Rule weights are written as WITH. FCL has no rule
+// gains, so a rule with a gain concludes on its output term stretched by the
+// gain, which gives the same output: in place when every rule concluding on
+// the term has that gain, else on a copy named after the term. With the
+// weighted average the output RANGE widens to take in the stretched peaks,
+// which a gain may carry past it. Rules with TSK consequents, input scales,
+// output gains and type-2 terms cannot be written.
+func WriteFCL(w io.Writer, c *Controller) error {
+	if len(c.Scales) > 0 || c.outputGain() != 1 {
+		return fmt.Errorf("fcl: FCL has no input scales or output gain")
+	}
+	if c.Type2() {
+		return fmt.Errorf("fcl: FCL has no type-2 terms")
+	}
+	output, thens, err := gainTerms(c)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	name := c.Name
+	if name == "" {
+		name = "controller"
+	}
+
+	fmt.Fprintf(bw, "FUNCTION_BLOCK %s\n\nVAR_INPUT\n", name)
+	for _, v := range c.Inputs {
+		fmt.Fprintf(bw, "\t%s : REAL;\n", v.Name)
+	}
+	fmt.Fprintf(bw, "END_VAR\n\nVAR_OUTPUT\n\t%s : REAL;\nEND_VAR\n", c.Output.Name)
+
+	for _, v := range c.Inputs {
+		fmt.Fprintf(bw, "\nFUZZIFY %s\n", v.Name)
+		if err := writeFCLTerms(bw, v); err != nil {
+			return err
+		}
+		fmt.Fprintf(bw, "\tRANGE := (%s .. %s);\nEND_FUZZIFY\n", fclNumber(v.Min), fclNumber(v.Max))
+	}
+
+	fmt.Fprintf(bw, "\nDEFUZZIFY %s\n", c.Output.Name)
+	if err := writeFCLTerms(bw, output); err != nil {
+		return err
+	}
+	d := c.Defuzzifier
+	if d == nil {
+		d = WeightedAverage{}
+	}
+	method := ""
+	for m, fd := range fclMethods {
+		if fd.String() == d.String() {
+			method = m
+		}
+	}
+	if method == "" {
+		return fmt.Errorf("fcl: defuzzifier %s has no FCL method", d)
+	}
+	fmt.Fprintf(bw, "\tMETHOD : %s;\n", method)
+	if c.NoFire == NoFireHold {
+		fmt.Fprintf(bw, "\tDEFAULT := NC;\n")
+	} else {
+		fmt.Fprintf(bw, "\tDEFAULT := %s;\n", fclNumber(c.Default))
+	}
+	fmt.Fprintf(bw, "\tRANGE := (%s .. %s);\nEND_DEFUZZIFY\n", fclNumber(output.Min), fclNumber(output.Max))
+
+	act := map[Implication]string{Clip: "MIN", Scale: "PROD"}[c.Implication]
+	accu := map[Aggregation]string{AggregateMax: "MAX", AggregateSum: "SUM"}[c.Aggregation]
+	fmt.Fprintf(bw, "\nRULEBLOCK rules\n\tAND : %s;\n\tOR : %s;\n\tACT : %s;\n\tACCU : %s;\n\n",
+		fclOperatorName("AND", c.Operators), fclOperatorName("OR", c.Operators), act, accu)
+	for i, r := range c.Rules {
+		fmt.Fprintf(bw, "\tRULE %d : IF %s THEN %s IS %s", i+1, r.If, c.Output.Name, thens[i])
+		if r.Weight != 0 && r.Weight != 1 {
+			fmt.Fprintf(bw, " WITH %s", fclNumber(r.Weight))
+		}
+		fmt.Fprintf(bw, ";\n")
+	}
+	fmt.Fprintf(bw, "END_RULEBLOCK\n\nEND_FUNCTION_BLOCK\n")
+	return bw.Flush()
+}
+
+// gainTerms returns the output variable of c with its terms stretched by the
+// gains of the rules concluding on them, and the term every rule concludes
+// on. A term whose rules all share one gain is stretched in place; otherwise
+// every other gain gets a copy named after the term, and the term itself is
+// kept only if a rule without a gain still concludes on it.
+func gainTerms(c *Controller) (*Variable, []string, error) {
+	_, average := c.defuzzifier().(WeightedAverage)
+	gains := map[string][]float64{}
+	for i, r := range c.Rules {
+		if r.TSK != nil {
+			return nil, nil, fmt.Errorf("fcl: rule %d has a TSK consequent", i+1)
+		}
+		if !containsFloat(gains[r.Then], r.gain()) {
+			gains[r.Then] = append(gains[r.Then], r.gain())
+		}
+	}
+
+	out := *c.Output
+	out.Terms = nil
+	names := map[string]string{} // "term gain" to the term written for it
+	for _, t := range c.Output.Terms {
+		gs := gains[t.Name]
+		if len(gs) == 0 || containsFloat(gs, 1) {
+			out.Terms = append(out.Terms, t)
+			names[fmt.Sprintf("%s %g", t.Name, 1.0)] = t.Name
+		}
+		n := 1
+		for _, g := range gs {
+			if g == 1 {
+				continue
+			}
+			mf, err := rescale(t.MF, g)
+			if err != nil {
+				return nil, nil, fmt.Errorf("fcl: gain %g of %s: %w", g, t.Name, err)
+			}
+			name := t.Name
+			if len(gs) > 1 {
+				for taken := true; taken; n++ {
+					name = fmt.Sprintf("%s_GAIN%d", t.Name, n)
+					_, taken = c.Output.Term(name)
+				}
+			}
+			out.Terms = append(out.Terms, Term{Name: name, MF: mf})
+			names[fmt.Sprintf("%s %g", t.Name, g)] = name
+			if average {
+				peak := g * c.peak(t)
+				out.Min, out.Max = math.Min(out.Min, peak), math.Max(out.Max, peak)
+			}
+		}
+	}
+
+	thens := make([]string, len(c.Rules))
+	for i, r := range c.Rules {
+		name, ok := names[fmt.Sprintf("%s %g", r.Then, r.gain())]
+		if !ok {
+			return nil, nil, fmt.Errorf("fcl: rule %d: unknown output term %s", i+1, r.Then)
+		}
+		thens[i] = name
+	}
+	return &out, thens, nil
+}
+
+func containsFloat(xs []float64, x float64) bool {
+	for _, y := range xs {
+		if y == x {
+			return true
+		}
+	}
+	return false
+}
+
+func writeFCLTerms(w io.Writer, v *Variable) error {
+	for _, t := range v.Terms {
+		def, err := fclMembership(t.MF)
+		if err != nil {
+			return fmt.Errorf("fcl: term %s of %s: %w", t.Name, v.Name, err)
+		}
+		fmt.Fprintf(w, "\tTERM %s := %s;\n", t.Name, def)
+	}
+	return nil
+}
+
+func fclMembership(mf MembershipFunction) (string, error) {
+	switch mf := mf.(type) {
+	case Triangular:
+		return "trian " + fclNumbers(mf.A, mf.B, mf.C), nil
+	case Trapezoidal:
+		if anyInf(mf.A, mf.B, mf.C, mf.D) {
+			// Shoulders become point lists, which stay flat past their ends.
+			var pts PiecewiseLinear
+			for _, pt := range []Point{{mf.A, 0}, {mf.B, 1}, {mf.C, 1}, {mf.D, 0}} {
+				if !math.IsInf(pt.X, 0) {
+					pts = append(pts, pt)
+				}
+			}
+			return fclMembership(pts)
+		}
+		return "trape " + fclNumbers(mf.A, mf.B, mf.C, mf.D), nil
+	case Gaussian:
+		return "gauss " + fclNumbers(mf.Mu, mf.Sigma), nil
+	case Bell:
+		return "gbell " + fclNumbers(mf.A, mf.B, mf.C), nil
+	case Sigmoid:
+		return "sigm " + fclNumbers(mf.A, mf.C), nil
+	case DiffSigmoid:
+		return "dsigm " + fclNumbers(mf.A1, mf.C1, mf.A2, mf.C2), nil
+	case Pi:
+		return "pi " + fclNumbers(mf.A, mf.B, mf.C, mf.D), nil
+	case SShape:
+		return "smf " + fclNumbers(mf.A, mf.B), nil
+	case ZShape:
+		return "zmf " + fclNumbers(mf.A, mf.B), nil
+	case Singleton:
+		return fclNumber(mf.X), nil
+	case PiecewiseLinear:
+		if len(mf) == 0 {
+			return "", fmt.Errorf("no points")
+		}
+		parts := make([]string, len(mf))
+		for i, pt := range mf {
+			parts[i] = fmt.Sprintf("(%s, %s)", fclNumber(pt.X), fclNumber(pt.Y))
+		}
+		return strings.Join(parts, " "), nil
+	default:
+		return "", fmt.Errorf("unsupported membership function %T", mf)
+	}
+}
+
+func anyInf(xs ...float64) bool {
+	for _, x := range xs {
+		if math.IsInf(x, 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// fclNumber formats x with 12 significant digits, which drops the noise a
+// gain leaves in stretched terms.
+func fclNumber(x float64) string {
+	return strconv.FormatFloat(x, 'g', 12, 64)
+}
+
+func fclNumbers(xs ...float64) string {
+	parts := make([]string, len(xs))
+	for i, x := range xs {
+		parts[i] = fclNumber(x)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,218 @@
+package fuzzy
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+const testFCL = `FUNCTION_BLOCK test
+
+VAR_INPUT
+	x : REAL;
+END_VAR
+
+VAR_OUTPUT
+	y : REAL;
+END_VAR
+
+FUZZIFY x
+	TERM ALL := trape -1 0 1 2;
+	RANGE := (0 .. 1);
+END_FUZZIFY
+
+DEFUZZIFY y
+	TERM LOW := trian -1 0 1;
+	TERM HIGH := trian 9 10 11;
+	METHOD : COG;
+	DEFAULT := 0;
+	RANGE := (0 .. 10);
+END_DEFUZZIFY
+
+RULEBLOCK rules
+	AND : MIN;
+	OR : MAX;
+	ACT : MIN;
+	ACCU : MAX;
+	RULE 1 : IF x IS ALL THEN y IS LOW;
+	RULE 2 : IF x IS ALL THEN y IS HIGH WITH 0.5;
+END_RULEBLOCK
+
+END_FUNCTION_BLOCK
+`
+
+func TestReadFCL(t *testing.T) {
+	c, err := ReadFCL(strings.NewReader(testFCL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "test" || len(c.Inputs) != 1 || c.Output.Name != "y" || len(c.Rules) != 2 {
+		t.Fatalf("read %s with %d inputs, output %s and %d rules", c.Name, len(c.Inputs), c.Output.Name, len(c.Rules))
+	}
+	if _, ok := c.Defuzzifier.(Centroid); !ok {
+		t.Errorf("METHOD : COG read as %v", c.Defuzzifier)
+	}
+	if c.Rules[1].Weight != 0.5 {
+		t.Errorf("WITH 0.5 read as weight %g", c.Rules[1].Weight)
+	}
+}
+
+func TestReadFCLErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name, old, new, want string
+	}{
+		{"no function block", "FUNCTION_BLOCK test", "FUNCTION test", `line 1: expected FUNCTION_BLOCK, got "FUNCTION"`},
+		{"unterminated comment", "END_FUNCTION_BLOCK", "END_FUNCTION_BLOCK (* end", "unterminated comment"},
+		{"unexpected character", "RANGE := (0 .. 1);", "RANGE := (0 .. 1) @", `line 13: unexpected '@'`},
+		{"truncated", "END_FUNCTION_BLOCK\n", "", "expected END_FUNCTION_BLOCK, got end of file"},
+		{"unexpected block", "RULEBLOCK rules", "RULES rules", `unexpected "RULES"`},
+		{"missing defuzzify", testFCL[strings.Index(testFCL, "DEFUZZIFY y"):strings.Index(testFCL, "END_FUNCTION_BLOCK")], "", "missing DEFUZZIFY block"},
+		{"variable declaration", "x : REAL;", "x REAL;", `line 4: expected :, got "REAL"`},
+		{"unexpected in fuzzify", "TERM ALL", "TERMS ALL", `unexpected "TERMS" in FUZZIFY x`},
+		{"unknown shape", "trape -1 0 1 2", "trapezoid -1 0 1 2", `unknown membership function "trapezoid"`},
+		{"missing parameter", "trian 9 10 11;", "trian 9 10;", `line 18: expected a number, got ";"`},
+		{"range", "(0 .. 10)", "(0 , 10)", `expected .., got ","`},
+		{"point", "TERM LOW := trian -1 0 1;", "TERM LOW := (0, 1) (1 0);", `expected ,, got "0"`},
+		{"method", "METHOD : COG;", "METHOD : MEDIAN;", "unsupported METHOD MEDIAN"},
+		{"default", "DEFAULT := 0;", "DEFAULT := none;", `expected a number, got "none"`},
+		{"accumulation", "ACCU : MAX;", "ACCU : PROD;", "unsupported ACCU PROD"},
+		{"activation", "ACT : MIN;", "ACT : MAX;", "unsupported ACT MAX"},
+		{"operator", "AND : MIN;", "AND : MAX;", "unsupported AND operator MAX"},
+		{"operator family", "OR : MAX;", "OR : ASUM;", "AND : MIN and OR : ASUM are not of the same family"},
+		{"unknown input", "IF x IS ALL THEN y IS LOW", "IF z IS ALL THEN y IS LOW", "unknown input variable z"},
+		{"unknown input term", "IF x IS ALL THEN y IS LOW", "IF x IS SOME THEN y IS LOW", "unknown term SOME of x"},
+		{"conclusion", "THEN y IS LOW", "THEN x IS LOW", "rule concludes on x, which is not the output variable"},
+		{"unknown output term", "THEN y IS LOW", "THEN y IS MID", "unknown term MID of y"},
+		{"missing semicolon", "y IS LOW;", "y IS LOW", `line 30: expected ;, got "RULE"`},
+		{"weight", "WITH 0.5", "WITH 2", "rule 2: weight 2 is outside (0, 1]"},
+		{"weight 0", "WITH 0.5", "WITH 0", "line 30: WITH 0 never fires the rule"},
+		{"invalid term", "trian 9 10 11", "trian 11 10 9", "y: term HIGH: triangular needs"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if !strings.Contains(testFCL, tc.old) {
+				t.Fatalf("%q is not in the test block", tc.old)
+			}
+			_, err := ReadFCL(strings.NewReader(strings.Replace(testFCL, tc.old, tc.new, 1)))
+			if err == nil {
+				t.Fatalf("ReadFCL accepted the block, want %q", tc.want)
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("ReadFCL = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+// TestFCLRoundTrip checks that controllers written by WriteFCL read back
+// with the same outputs, rule gains included.
+func TestFCLRoundTrip(t *testing.T) {
+	gains := benchController()
+	for i := range gains.Rules {
+		gains.Rules[i].Gain = []float64{1.5, 1.5, 1, 1, 0.5, 0.5, 0.3}[i]
+	}
+	centroid := benchController()
+	centroid.Defuzzifier = Centroid{}
+	centroid.Operators, centroid.Implication, centroid.Aggregation = Product, Scale, AggregateSum
+	centroid.Rules[2].Weight = 0.4
+	hold := weightController(0.5, 2)
+	hold.Defuzzifier, hold.NoFire, hold.Operators = MeanOfMaximum{}, NoFireHold, Drastic
+
+	for _, c := range []*Controller{benchController(), gains, centroid, hold} {
+		var buf bytes.Buffer
+		if err := WriteFCL(&buf, c); err != nil {
+			t.Fatal(err)
+		}
+		back, err := ReadFCL(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("%v\n%s", err, buf.String())
+		}
+		if back.NoFire != c.NoFire || back.defuzzifier() != c.defuzzifier() ||
+			back.Operators != c.Operators || back.Implication != c.Implication || back.Aggregation != c.Aggregation {
+			t.Errorf("settings changed in\n%s", buf.String())
+		}
+		in := c.Inputs[0]
+		for i := 0; i <= 40; i++ {
+			x := in.Min + (in.Max-in.Min)*float64(i)/40
+			want, err := c.Evaluate(map[string]float64{in.Name: x})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := back.Evaluate(map[string]float64{in.Name: x})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(got-want) > 1e-6*math.Max(1, math.Abs(want)) {
+				t.Errorf("%s = %g: output %g after the round trip, want %g\n%s", in.Name, x, got, want, buf.String())
+				break
+			}
+		}
+	}
+}
+
+// TestWriteFCLGains checks that rule gains stretch the output terms in place
+// when every rule concluding on a term shares the gain, that the terms left
+// unused are dropped, and that the stretched parameters are written without
+// floating point noise.
+func TestWriteFCLGains(t *testing.T) {
+	c := benchController()
+	for i := range c.Rules {
+		c.Rules[i].Gain = []float64{1.5, 1.5, 1, 1, 0.5, 0.5, 0.3}[i]
+	}
+	var buf bytes.Buffer
+	if err := WriteFCL(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+	src := buf.String()
+	for _, want := range []string{
+		"TERM LD := gauss -3 0.75;",
+		"TERM SD := gauss -1.5 0.5;",
+		"TERM SI := gauss 0.75 0.25;",
+		"TERM LI_GAIN1 := gauss 1.5 0.25;",
+		"TERM LI_GAIN2 := gauss 0.9 0.15;",
+		"RULE 7 : IF error IS LP THEN output IS LI_GAIN2;",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("missing %q in\n%s", want, src)
+		}
+	}
+	if strings.Contains(src, "TERM LI :=") {
+		t.Errorf("unused term LI written in\n%s", src)
+	}
+
+	back, err := ReadFCL(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := Analyze(back, AnalysisOptions{Samples: 11})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.UnusedTerms) > 0 {
+		t.Errorf("unused terms %v in\n%s", r.UnusedTerms, src)
+	}
+}
+
+func TestWriteFCLErrors(t *testing.T) {
+	tsk := weightController(0, 0)
+	tsk.Rules[0].TSK = &Linear{Const: 1}
+	scaled := weightController(0, 0)
+	scaled.OutputGain = 2
+	type2 := weightController(0, 0)
+	type2.Inputs[0].Terms[0].Lower = Trapezoidal{-0.5, 0.5, 0.5, 1.5}
+
+	for _, tc := range []struct {
+		name string
+		c    *Controller
+		want string
+	}{
+		{"TSK", tsk, "rule 1 has a TSK consequent"},
+		{"output gain", scaled, "FCL has no input scales or output gain"},
+		{"type-2", type2, "FCL has no type-2 terms"},
+	} {
+		err := WriteFCL(&bytes.Buffer{}, tc.c)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: WriteFCL = %v, want %q", tc.name, err, tc.want)
+		}
+	}
+}
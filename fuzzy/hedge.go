@@ -0,0 +1,49 @@
+package fuzzy
+
+import (
+	"math"
+	"strings"
+)
+
+// Hedge modifies the degree of a clause, as in "error IS very LP". The
+// concentrating and dilating hedges raise the degree to a power.
+type Hedge int
+
+const (
+	Very       Hedge = iota // μ²
+	Somewhat                // μ^(1/2)
+	Extremely               // μ³
+	Slightly                // μ^(1/3)
+	Complement              // not: 1 - μ
+)
+
+var hedgeNames = map[Hedge]string{
+	Very: "very", Somewhat: "somewhat", Extremely: "extremely", Slightly: "slightly", Complement: "not",
+}
+
+func (h Hedge) String() string { return hedgeNames[h] }
+
+func (h Hedge) apply(mu float64) float64 {
+	switch h {
+	case Very:
+		return mu * mu
+	case Somewhat:
+		return math.Sqrt(mu)
+	case Extremely:
+		return mu * mu * mu
+	case Slightly:
+		return math.Cbrt(mu)
+	default:
+		return 1 - mu
+	}
+}
+
+// parseHedge returns the hedge called name, in any case.
+func parseHedge(name string) (Hedge, bool) {
+	for h, n := range hedgeNames {
+		if strings.EqualFold(n, name) {
+			return h, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,68 @@
+package fuzzy
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHedges(t *testing.T) {
+	for _, tc := range []struct {
+		h    Hedge
+		want float64
+	}{
+		{Very, 0.4096},
+		{Somewhat, 0.8},
+		{Extremely, 0.262144},
+		{Slightly, math.Cbrt(0.64)},
+		{Complement, 0.36},
+	} {
+		if got := tc.h.apply(0.64); math.Abs(got-tc.want) > 1e-12 {
+			t.Errorf("%s 0.64 = %g, want %g", tc.h, got, tc.want)
+		}
+	}
+}
+
+// TestHedgeOrder checks that hedges apply from the one nearest the term
+// outwards, as they read: "very not A" is the square of the complement,
+// "not very A" the complement of the square.
+func TestHedgeOrder(t *testing.T) {
+	m := Memberships{"x": {"A": 0.6}}
+	for _, tc := range []struct {
+		src  string
+		want float64
+	}{
+		{"x IS very not A", 0.16},
+		{"x IS not very A", 0.64},
+		{"x IS somewhat very A", 0.6},
+		{"x IS extremely slightly A", 0.6},
+		{"x IS VERY Somewhat A", 0.6},
+		{"x IS very very A", 0.1296},
+		{"NOT x IS not A", 0.6},
+	} {
+		a, err := ParseAntecedent(tc.src)
+		if err != nil {
+			t.Errorf("%s: %v", tc.src, err)
+			continue
+		}
+		if d := a.Degree(m, MinMax); math.Abs(d-tc.want) > 1e-12 {
+			t.Errorf("%s: degree %g, want %g", tc.src, d, tc.want)
+		}
+		back, err := ParseAntecedent(a.String())
+		if err != nil {
+			t.Errorf("%s: written as %q: %v", tc.src, a, err)
+			continue
+		}
+		if d := back.Degree(m, MinMax); math.Abs(d-tc.want) > 1e-12 {
+			t.Errorf("%s: written as %q, degree %g after reading back, want %g", tc.src, a, d, tc.want)
+		}
+	}
+
+	// A hedge name followed by a keyword is the term itself.
+	a, err := ParseAntecedent("x IS very AND x IS A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if and, ok := a.(And); !ok || and[0].(Is).Term != "very" || len(and[0].(Is).Hedges) != 0 {
+		t.Errorf("x IS very AND x IS A parsed as %#v", a)
+	}
+}
@@ -0,0 +1,138 @@
+package fuzzy
+
+import (
+	"fmt"
+	"math"
+)
+
+// DefaultResolution is the number of points the output universe is sampled
+// at when a controller does not set its own Resolution.
+const DefaultResolution = 201
+
+// Implication shapes a consequent term by the firing strength of its rule.
+type Implication int
+
+const (
+	Clip  Implication = iota // min(strength, μ)
+	Scale                    // strength · μ
+)
+
+func (i Implication) apply(strength, mu float64) float64 {
+	if i == Scale {
+		return strength * mu
+	}
+	return math.Min(strength, mu)
+}
+
+var implicationNames = map[Implication]string{Clip: "min", Scale: "prod"}
+
+func (i Implication) String() string { return implicationNames[i] }
+
+// Set implements flag.Value.
+func (i *Implication) Set(s string) error {
+	for k, name := range implicationNames {
+		if name == s {
+			*i = k
+			return nil
+		}
+	}
+	return fmt.Errorf("fuzzy: unknown implication %q", s)
+}
+
+// Aggregation combines the implied consequents of all rules.
+type Aggregation int
+
+const (
+	AggregateMax Aggregation = iota // max of the implied sets
+	AggregateSum                    // sum of the implied sets, unbounded
+)
+
+func (a Aggregation) apply(acc, mu float64) float64 {
+	if a == AggregateSum {
+		return acc + mu
+	}
+	return math.Max(acc, mu)
+}
+
+var aggregationNames = map[Aggregation]string{AggregateMax: "max", AggregateSum: "sum"}
+
+func (a Aggregation) String() string { return aggregationNames[a] }
+
+// Set implements flag.Value.
+func (a *Aggregation) Set(s string) error {
+	for k, name := range aggregationNames {
+		if name == s {
+			*a = k
+			return nil
+		}
+	}
+	return fmt.Errorf("fuzzy: unknown aggregation %q", s)
+}
+
+func (c *Controller) resolution() int {
+	if c.Resolution < 2 {
+		return DefaultResolution
+	}
+	return c.Resolution
+}
+
+// Activation is a fired rule: its consequent term, how strongly it fired and
+// the representative value of the consequent, scaled by the rule gain. Term
+// is empty for TSK rules, whose Value is the value of their linear
+// consequent. Strength is the degree of the antecedent, before the rule
+// weight applies.
+//
+// Rules of controllers with interval type-2 terms fire with a strength
+// anywhere between Lower and Strength. Lower equals Strength otherwise.
+type Activation struct {
+	Rule     Rule
+	Term     Term
+	Strength float64
+	Lower    float64
+	Value    float64
+}
+
+// Output is the result of firing every rule of a controller, before
+// defuzzification.
+type Output struct {
+	Activations []Activation
+
+	c       *Controller
+	in      map[string]float64 // scaled inputs
+	tsk     bool               // some rules have TSK consequents
+	type2   bool               // some input terms are interval type-2
+	yl, yr  float64            // type-reduced output interval, when type2
+	xs, mus []float64
+	sampled bool
+}
+
+// Surface returns the aggregated output set sampled at Resolution evenly
+// spaced points of the output universe. Every consequent term, stretched by
+// the rule gain, is implied by the rule firing strength times the rule
+// weight. Singleton terms only show at the samples they fall on, which is
+// why Validate keeps them to the weighted average.
+func (o *Output) Surface() (xs, mus []float64) {
+	if o.sampled {
+		return o.xs, o.mus
+	}
+
+	c := o.c
+	n := c.resolution()
+	step := (c.Output.Max - c.Output.Min) / float64(n-1)
+
+	o.xs = make([]float64, n)
+	o.mus = make([]float64, n)
+	for i := range o.xs {
+		x := c.Output.Min + float64(i)*step
+		mu := 0.0
+		for _, a := range o.Activations {
+			if a.Strength == 0 {
+				continue
+			}
+			mu = c.Aggregation.apply(mu, c.Implication.apply(a.Strength*a.Rule.weight(), a.Term.MF.Eval(x/a.Rule.gain())))
+		}
+		o.xs[i], o.mus[i] = x, mu
+	}
+	o.sampled = true
+	return o.xs, o.mus
+}
@@ -0,0 +1,69 @@
+package fuzzy
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LoadFile reads and validates a controller definition, choosing the format
+// from the file extension: .fcl for Fuzzy Control Language, .json or
+// .yaml/.yml for a Definition.
+func LoadFile(path string) (*Controller, error) {
+	read, err := reader(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return read(f)
+}
+
+// SaveFile writes c to path in the format its extension selects, as
+// LoadFile reads it.
+func SaveFile(path string, c *Controller) error {
+	write, err := writer(path)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f, c); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func reader(path string) (func(io.Reader) (*Controller, error), error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".fcl":
+		return ReadFCL, nil
+	case ".json":
+		return ReadJSON, nil
+	case ".yaml", ".yml":
+		return ReadYAML, nil
+	default:
+		return nil, fmt.Errorf("fuzzy: unknown controller format %q", ext)
+	}
+}
+
+func writer(path string) (func(io.Writer, *Controller) error, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".fcl":
+		return WriteFCL, nil
+	case ".json":
+		return WriteJSON, nil
+	case ".yaml", ".yml":
+		return WriteYAML, nil
+	default:
+		return nil, fmt.Errorf("fuzzy: unknown controller format %q", ext)
+	}
+}
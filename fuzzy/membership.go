@@ -0,0 +1,265 @@
+package fuzzy
+
+import "math"
+
+// MembershipFunction is the shape of a fuzzy set.
+type MembershipFunction interface {
+	// Eval returns the degree of membership of x, in [0, 1].
+	Eval(x float64) float64
+	// Support returns the interval outside of which the membership is zero.
+	// Either bound may be infinite.
+	Support() (lo, hi float64)
+	// Peak returns a point where the membership is highest. For shoulders
+	// whose plateau is open-ended it is the inner edge of the plateau.
+	Peak() float64
+}
+
+// Gaussian is a gaussian curve centred on Mu.
+type Gaussian struct {
+	Mu, Sigma float64
+}
+
+func (g Gaussian) Eval(x float64) float64 {
+	return math.Exp(-math.Pow(x-g.Mu, 2) / (2 * math.Pow(g.Sigma, 2)))
+}
+
+func (g Gaussian) Support() (float64, float64) { return math.Inf(-1), math.Inf(1) }
+func (g Gaussian) Peak() float64               { return g.Mu }
+
+// Triangular rises from A, peaks at B and falls back to zero at C. A == B or
+// B == C give a vertical edge.
+type Triangular struct {
+	A, B, C float64
+}
+
+func (t Triangular) Eval(x float64) float64 {
+	switch {
+	case x < t.A || x > t.C:
+		return 0
+	case x < t.B:
+		return (x - t.A) / (t.B - t.A)
+	case x > t.B:
+		return (t.C - x) / (t.C - t.B)
+	default:
+		return 1
+	}
+}
+
+func (t Triangular) Support() (float64, float64) { return t.A, t.C }
+func (t Triangular) Peak() float64               { return t.B }
+
+// Bell is a generalized bell curve of width A, slope B and centre C.
+type Bell struct {
+	A, B, C float64
+}
+
+func (b Bell) Eval(x float64) float64 {
+	return 1.0 / (1.0 + math.Pow(math.Abs((x-b.C)/b.A), 2*b.B))
+}
+
+func (b Bell) Support() (float64, float64) { return math.Inf(-1), math.Inf(1) }
+func (b Bell) Peak() float64               { return b.C }
+
+// Trapezoidal rises from A to B, stays at 1 until C and falls to zero at D.
+// Setting A and B to -Inf, or C and D to +Inf, gives a shoulder that
+// saturates at 1 towards that end of the universe.
+type Trapezoidal struct {
+	A, B, C, D float64
+}
+
+func (t Trapezoidal) Eval(x float64) float64 {
+	switch {
+	case x < t.A || x > t.D:
+		return 0
+	case x < t.B:
+		return (x - t.A) / (t.B - t.A)
+	case x <= t.C:
+		return 1
+	default:
+		return (t.D - x) / (t.D - t.C)
+	}
+}
+
+func (t Trapezoidal) Support() (float64, float64) { return t.A, t.D }
+
+func (t Trapezoidal) Peak() float64 {
+	switch {
+	case math.IsInf(t.C, 1):
+		return t.B
+	case math.IsInf(t.B, -1):
+		return t.C
+	default:
+		return (t.B + t.C) / 2
+	}
+}
+
+// Sigmoid is 1 / (1 + exp(-A (x - C))). A positive A opens to the right, a
+// negative one to the left.
+type Sigmoid struct {
+	A, C float64
+}
+
+func (s Sigmoid) Eval(x float64) float64 {
+	return 1 / (1 + math.Exp(-s.A*(x-s.C)))
+}
+
+func (s Sigmoid) Support() (float64, float64) { return math.Inf(-1), math.Inf(1) }
+
+// Peak returns the point where the sigmoid reaches 0.99, since it never
+// reaches 1.
+func (s Sigmoid) Peak() float64 {
+	return s.C + math.Log(99)/s.A
+}
+
+// DiffSigmoid is the difference of two sigmoids, clipped at zero. With both
+// slopes positive and C1 < C2 it is a smooth bump between C1 and C2.
+type DiffSigmoid struct {
+	A1, C1, A2, C2 float64
+}
+
+func (d DiffSigmoid) Eval(x float64) float64 {
+	v := Sigmoid{d.A1, d.C1}.Eval(x) - Sigmoid{d.A2, d.C2}.Eval(x)
+	return math.Max(0, math.Min(1, v))
+}
+
+func (d DiffSigmoid) Support() (float64, float64) { return math.Inf(-1), math.Inf(1) }
+
+// Peak locates the maximum with a ternary search between the two centres,
+// widened by a few slope lengths on each side.
+func (d DiffSigmoid) Peak() float64 {
+	lo := math.Min(d.C1, d.C2) - 5/math.Abs(d.A1)
+	hi := math.Max(d.C1, d.C2) + 5/math.Abs(d.A2)
+	for i := 0; i < 100; i++ {
+		m1 := lo + (hi-lo)/3
+		m2 := hi - (hi-lo)/3
+		if d.Eval(m1) < d.Eval(m2) {
+			lo = m1
+		} else {
+			hi = m2
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// SShape is a smooth spline rising from 0 at A to 1 at B, staying at 1
+// beyond B.
+type SShape struct {
+	A, B float64
+}
+
+func (s SShape) Eval(x float64) float64 {
+	switch mid := (s.A + s.B) / 2; {
+	case x <= s.A:
+		return 0
+	case x <= mid:
+		return 2 * math.Pow((x-s.A)/(s.B-s.A), 2)
+	case x < s.B:
+		return 1 - 2*math.Pow((x-s.B)/(s.B-s.A), 2)
+	default:
+		return 1
+	}
+}
+
+func (s SShape) Support() (float64, float64) { return s.A, math.Inf(1) }
+func (s SShape) Peak() float64               { return s.B }
+
+// ZShape is the mirror of SShape: 1 up to A, falling smoothly to 0 at B.
+type ZShape struct {
+	A, B float64
+}
+
+func (z ZShape) Eval(x float64) float64 {
+	return 1 - SShape(z).Eval(x)
+}
+
+func (z ZShape) Support() (float64, float64) { return math.Inf(-1), z.B }
+func (z ZShape) Peak() float64               { return z.A }
+
+// Pi rises like an SShape from A to B, stays at 1 until C and falls like a
+// ZShape to zero at D.
+type Pi struct {
+	A, B, C, D float64
+}
+
+func (p Pi) Eval(x float64) float64 {
+	return SShape{p.A, p.B}.Eval(x) * ZShape{p.C, p.D}.Eval(x)
+}
+
+func (p Pi) Support() (float64, float64) { return p.A, p.D }
+func (p Pi) Peak() float64               { return (p.B + p.C) / 2 }
+
+// Singleton has membership 1 at X and 0 everywhere else.
+type Singleton struct {
+	X float64
+}
+
+func (s Singleton) Eval(x float64) float64 {
+	if x == s.X {
+		return 1
+	}
+	return 0
+}
+
+func (s Singleton) Support() (float64, float64) { return s.X, s.X }
+func (s Singleton) Peak() float64               { return s.X }
+
+// Point is a vertex of a PiecewiseLinear membership function.
+type Point struct {
+	X, Y float64
+}
+
+// PiecewiseLinear joins its points, sorted by X, with straight lines. Before
+// the first point and after the last one the membership stays at their
+// degree, as in the point lists of IEC 61131-7 FCL.
+type PiecewiseLinear []Point
+
+func (p PiecewiseLinear) Eval(x float64) float64 {
+	if len(p) == 0 {
+		return 0
+	}
+	if x <= p[0].X {
+		return p[0].Y
+	}
+	for i := 1; i < len(p); i++ {
+		if x <= p[i].X {
+			a, b := p[i-1], p[i]
+			return a.Y + (b.Y-a.Y)*(x-a.X)/(b.X-a.X)
+		}
+	}
+	return p[len(p)-1].Y
+}
+
+func (p PiecewiseLinear) Support() (float64, float64) {
+	lo, hi := math.Inf(-1), math.Inf(1)
+	if len(p) == 0 {
+		return 0, 0
+	}
+	if p[0].Y == 0 {
+		lo = p[0].X
+	}
+	if p[len(p)-1].Y == 0 {
+		hi = p[len(p)-1].X
+	}
+	return lo, hi
+}
+
+// Peak returns the middle of the highest stretch of points.
+func (p PiecewiseLinear) Peak() float64 {
+	if len(p) == 0 {
+		return 0
+	}
+	max := p[0].Y
+	for _, pt := range p {
+		max = math.Max(max, pt.Y)
+	}
+	first, last := -1, -1
+	for i, pt := range p {
+		if pt.Y == max {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	return (p[first].X + p[last].X) / 2
+}
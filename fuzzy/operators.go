@@ -0,0 +1,91 @@
+package fuzzy
+
+import (
+	"fmt"
+	"math"
+)
+
+// Operators is the family of fuzzy AND (t-norm) and OR (s-norm) operators
+// compound antecedents are evaluated with. NOT is always 1 - x.
+type Operators int
+
+const (
+	MinMax      Operators = iota // AND min(a, b), OR max(a, b)
+	Product                      // AND a·b, OR probabilistic sum a + b - a·b
+	Lukasiewicz                  // AND max(0, a + b - 1), OR min(1, a + b)
+	Hamacher                     // AND a·b / (a + b - a·b), OR (a + b - 2a·b) / (1 - a·b)
+	Einstein                     // AND a·b / (2 - (a + b - a·b)), OR (a + b) / (1 + a·b)
+	Drastic                      // AND b if a = 1, a if b = 1, else 0; OR b if a = 0, a if b = 0, else 1
+)
+
+var operatorNames = map[Operators]string{
+	MinMax: "minmax", Product: "product", Lukasiewicz: "lukasiewicz", Hamacher: "hamacher",
+	Einstein: "einstein", Drastic: "drastic",
+}
+
+func (o Operators) String() string { return operatorNames[o] }
+
+// Set implements flag.Value.
+func (o *Operators) Set(s string) error {
+	for k, name := range operatorNames {
+		if name == s {
+			*o = k
+			return nil
+		}
+	}
+	return fmt.Errorf("fuzzy: unknown operators %q", s)
+}
+
+// and is the t-norm of the family.
+func (o Operators) and(a, b float64) float64 {
+	switch o {
+	case Product:
+		return a * b
+	case Lukasiewicz:
+		return math.Max(0, a+b-1)
+	case Hamacher:
+		if a == 0 && b == 0 {
+			return 0
+		}
+		return a * b / (a + b - a*b)
+	case Einstein:
+		return a * b / (2 - (a + b - a*b))
+	case Drastic:
+		switch {
+		case a == 1:
+			return b
+		case b == 1:
+			return a
+		}
+		return 0
+	default:
+		return math.Min(a, b)
+	}
+}
+
+// or is the s-norm of the family, the dual of and.
+func (o Operators) or(a, b float64) float64 {
+	switch o {
+	case Product:
+		return a + b - a*b
+	case Lukasiewicz:
+		return math.Min(1, a+b)
+	case Hamacher:
+		if a == 1 && b == 1 {
+			return 1
+		}
+		return (a + b - 2*a*b) / (1 - a*b)
+	case Einstein:
+		return (a + b) / (1 + a*b)
+	case Drastic:
+		switch {
+		case a == 0:
+			return b
+		case b == 0:
+			return a
+		}
+		return 1
+	default:
+		return math.Max(a, b)
+	}
+}
@@ -0,0 +1,101 @@
+package fuzzy
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestOperators(t *testing.T) {
+	for _, tc := range []struct {
+		ops     Operators
+		a, b    float64
+		and, or float64
+	}{
+		{MinMax, 0.3, 0.6, 0.3, 0.6},
+		{Product, 0.5, 0.4, 0.2, 0.7},
+		{Product, 1, 0.4, 0.4, 1},
+		{Lukasiewicz, 0.7, 0.6, 0.3, 1},
+		{Lukasiewicz, 0.3, 0.4, 0, 0.7},
+		{Hamacher, 0.5, 0.5, 1.0 / 3, 0.5 / 0.75},
+		{Hamacher, 0, 0, 0, 0},
+		{Hamacher, 1, 1, 1, 1},
+		{Einstein, 0.5, 0.5, 0.25 / 1.25, 1 / 1.25},
+		{Einstein, 1, 1, 1, 1},
+		{Drastic, 1, 0.4, 0.4, 1},
+		{Drastic, 0.9, 0.4, 0, 1},
+		{Drastic, 0, 0.4, 0, 0.4},
+	} {
+		if got := tc.ops.and(tc.a, tc.b); math.Abs(got-tc.and) > 1e-12 {
+			t.Errorf("%s: %g AND %g = %g, want %g", tc.ops, tc.a, tc.b, got, tc.and)
+		}
+		if got := tc.ops.or(tc.a, tc.b); math.Abs(got-tc.or) > 1e-12 {
+			t.Errorf("%s: %g OR %g = %g, want %g", tc.ops, tc.a, tc.b, got, tc.or)
+		}
+	}
+}
+
+// TestOperatorLaws checks on a grid that every family is a t-norm and its
+// dual s-norm: commutative, with 1 the identity of AND and 0 that of OR,
+// bounded by the minimum and maximum, and dual by the complement.
+func TestOperatorLaws(t *testing.T) {
+	grid := []float64{0, 0.1, 0.25, 0.5, 0.75, 0.9, 1}
+	for ops := range operatorNames {
+		for _, a := range grid {
+			if got := ops.and(a, 1); math.Abs(got-a) > 1e-12 {
+				t.Errorf("%s: %g AND 1 = %g", ops, a, got)
+			}
+			if got := ops.or(a, 0); math.Abs(got-a) > 1e-12 {
+				t.Errorf("%s: %g OR 0 = %g", ops, a, got)
+			}
+			for _, b := range grid {
+				and, or := ops.and(a, b), ops.or(a, b)
+				if and != ops.and(b, a) || or != ops.or(b, a) {
+					t.Errorf("%s: not commutative at %g, %g", ops, a, b)
+				}
+				if and > math.Min(a, b)+1e-12 || or < math.Max(a, b)-1e-12 {
+					t.Errorf("%s: %g AND %g = %g, OR = %g outside the min and max", ops, a, b, and, or)
+				}
+				if dual := 1 - ops.and(1-a, 1-b); math.Abs(or-dual) > 1e-12 {
+					t.Errorf("%s: %g OR %g = %g, the dual of AND gives %g", ops, a, b, or, dual)
+				}
+			}
+		}
+	}
+}
+
+// TestPrecedence checks that NOT binds tighter than AND, which binds
+// tighter than OR, and that parentheses override both.
+func TestPrecedence(t *testing.T) {
+	a, b, c := Is{Var: "x", Term: "A"}, Is{Var: "x", Term: "B"}, Is{Var: "x", Term: "C"}
+	m := Memberships{"x": {"A": 0.2, "B": 0.9, "C": 0.3}}
+	for _, tc := range []struct {
+		src    string
+		want   Antecedent
+		degree float64
+	}{
+		{"x IS A OR x IS B AND x IS C", Or{a, And{b, c}}, 0.3},
+		{"x IS A AND x IS B OR x IS C", Or{And{a, b}, c}, 0.3},
+		{"NOT x IS A AND x IS C", And{Not{a}, c}, 0.3},
+		{"NOT x IS B OR x IS A", Or{Not{b}, a}, 0.2},
+		{"x IS A OR NOT x IS B AND x IS C", Or{a, And{Not{b}, c}}, 0.2},
+		{"(x IS A OR x IS B) AND x IS C", And{Or{a, b}, c}, 0.3},
+		{"NOT (x IS A OR x IS B)", Not{Or{a, b}}, 0.1},
+	} {
+		got, err := ParseAntecedent(tc.src)
+		if err != nil {
+			t.Errorf("%s: %v", tc.src, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s parsed as %#v, want %#v", tc.src, got, tc.want)
+		}
+		if d := got.Degree(m, MinMax); math.Abs(d-tc.degree) > 1e-12 {
+			t.Errorf("%s: degree %g, want %g", tc.src, d, tc.degree)
+		}
+		back, err := ParseAntecedent(got.String())
+		if err != nil || !reflect.DeepEqual(back, got) {
+			t.Errorf("%s: written as %q, which reads back as %v (%v)", tc.src, got.String(), back, err)
+		}
+	}
+}
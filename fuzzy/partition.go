@@ -0,0 +1,191 @@
+package fuzzy
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Shape is the membership function shape of a generated partition.
+type Shape int
+
+const (
+	ShapeTriangular Shape = iota
+	ShapeGaussian
+	ShapeBell
+)
+
+var shapeNames = map[Shape]string{ShapeTriangular: "triangular", ShapeGaussian: "gaussian", ShapeBell: "bell"}
+
+func (s Shape) String() string { return shapeNames[s] }
+
+// Set implements flag.Value.
+func (s *Shape) Set(name string) error {
+	for k, n := range shapeNames {
+		if n == name {
+			*s = k
+			return nil
+		}
+	}
+	return fmt.Errorf("fuzzy: unknown shape %q", name)
+}
+
+// Spacing places the peaks of a generated partition.
+type Spacing int
+
+const (
+	// Uniform spaces the peaks evenly over the universe.
+	Uniform Spacing = iota
+	// Logarithmic packs the peaks towards zero, or towards the end of the
+	// universe nearest to zero when it does not contain zero, so that
+	// small values are told apart more finely than large ones.
+	Logarithmic
+)
+
+var spacingNames = map[Spacing]string{Uniform: "uniform", Logarithmic: "log"}
+
+func (s Spacing) String() string { return spacingNames[s] }
+
+// Set implements flag.Value.
+func (s *Spacing) Set(name string) error {
+	for k, n := range spacingNames {
+		if n == name {
+			*s = k
+			return nil
+		}
+	}
+	return fmt.Errorf("fuzzy: unknown spacing %q", name)
+}
+
+// PartitionOptions tune Partition.
+type PartitionOptions struct {
+	Shape   Shape
+	Spacing Spacing
+	// Names are the term names, as many as terms. Nil names the terms T1,
+	// T2, ...
+	Names []string
+	// Overlap is the membership at which neighbouring terms cross, in
+	// (0, 1). Zero means 0.5.
+	Overlap float64
+	// LogBase sets how strongly Logarithmic spacing packs the peaks: the
+	// peaks are at (LogBase^t - 1) / (LogBase - 1) of the distance to the
+	// far end, for evenly spaced t. Zero means 100.
+	LogBase float64
+	// BellSlope is the b parameter of ShapeBell terms. Zero means 2.
+	BellSlope float64
+}
+
+// Partition returns n terms of the given shape covering [min, max], the
+// first peaking at min and the last at max. Neighbouring terms cross at
+// opts.Overlap or above, so every point of the universe belongs to some
+// term.
+func Partition(min, max float64, n int, opts PartitionOptions) ([]Term, error) {
+	if !(min < max) {
+		return nil, fmt.Errorf("fuzzy: empty universe [%g, %g]", min, max)
+	}
+	if n < 2 {
+		return nil, errors.New("fuzzy: a partition needs at least 2 terms")
+	}
+	if opts.Names != nil && len(opts.Names) != n {
+		return nil, fmt.Errorf("fuzzy: %d names for %d terms", len(opts.Names), n)
+	}
+	o := opts.Overlap
+	if o == 0 {
+		o = 0.5
+	}
+	if !(o > 0 && o < 1) {
+		return nil, fmt.Errorf("fuzzy: overlap %g is outside (0, 1)", o)
+	}
+
+	peaks, err := partitionPeaks(min, max, n, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	terms := make([]Term, n)
+	for i, c := range peaks {
+		// Half the distance to the neighbouring peaks.
+		left, right := 0.0, 0.0
+		if i > 0 {
+			left = (c - peaks[i-1]) / 2
+		}
+		if i < n-1 {
+			right = (peaks[i+1] - c) / 2
+		}
+
+		var mf MembershipFunction
+		switch opts.Shape {
+		case ShapeTriangular:
+			// Each side reaches o half way to the neighbouring peak.
+			mf = Triangular{c - left/(1-o), c, c + right/(1-o)}
+			if i == 0 {
+				mf = Triangular{c, c, c + right/(1-o)}
+			} else if i == n-1 {
+				mf = Triangular{c - left/(1-o), c, c}
+			}
+		case ShapeGaussian:
+			// One sigma must serve both sides: the wider gap decides.
+			h := math.Max(left, right)
+			mf = Gaussian{c, h / math.Sqrt(2*math.Log(1/o))}
+		case ShapeBell:
+			b := opts.BellSlope
+			if b == 0 {
+				b = 2
+			}
+			h := math.Max(left, right)
+			mf = Bell{h / math.Pow(1/o-1, 1/(2*b)), b, c}
+		default:
+			return nil, fmt.Errorf("fuzzy: unknown shape %d", opts.Shape)
+		}
+
+		name := fmt.Sprintf("T%d", i+1)
+		if opts.Names != nil {
+			name = opts.Names[i]
+		}
+		terms[i] = Term{Name: name, MF: mf}
+	}
+	return terms, nil
+}
+
+func partitionPeaks(min, max float64, n int, opts PartitionOptions) ([]float64, error) {
+	peaks := make([]float64, n)
+	if opts.Spacing == Uniform {
+		for i := range peaks {
+			peaks[i] = min + float64(i)*(max-min)/float64(n-1)
+		}
+		return peaks, nil
+	}
+
+	base := opts.LogBase
+	if base == 0 {
+		base = 100
+	}
+	if !(base > 1) {
+		return nil, fmt.Errorf("fuzzy: log base %g is not above 1", base)
+	}
+	warp := func(t float64) float64 { return (math.Pow(base, t) - 1) / (base - 1) }
+
+	switch {
+	case min >= 0:
+		for i := range peaks {
+			peaks[i] = min + (max-min)*warp(float64(i)/float64(n-1))
+		}
+	case max <= 0:
+		for i := range peaks {
+			peaks[n-1-i] = max - (max-min)*warp(float64(i)/float64(n-1))
+		}
+	default:
+		// Zero gets the middle term, peaks[k], and each side is warped on
+		// its own.
+		if n%2 == 0 {
+			return nil, fmt.Errorf("fuzzy: logarithmic spacing around zero needs an odd number of terms, got %d", n)
+		}
+		k := n / 2
+		for i := 1; i <= k; i++ {
+			t := warp(float64(i) / float64(k))
+			peaks[k+i] = max * t
+			peaks[k-i] = min * t
+		}
+	}
+	return peaks, nil
+}
@@ -0,0 +1,173 @@
+package fuzzy
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// peaksOf returns the peaks of terms.
+func peaksOf(terms []Term) []float64 {
+	peaks := make([]float64, len(terms))
+	for i, t := range terms {
+		peaks[i] = t.MF.Peak()
+	}
+	return peaks
+}
+
+// TestPartitionOverlap checks that the terms peak at both ends of the
+// universe, in order, and that every point of the universe belongs to some
+// term at the overlap or above.
+func TestPartitionOverlap(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		min, max float64
+		n        int
+		opts     PartitionOptions
+	}{
+		{"triangular", 0, 10, 5, PartitionOptions{}},
+		{"triangular overlap 0.2", -5, 5, 4, PartitionOptions{Overlap: 0.2}},
+		{"gaussian", 0, 10, 5, PartitionOptions{Shape: ShapeGaussian}},
+		{"bell slope 4", 0, 10, 3, PartitionOptions{Shape: ShapeBell, BellSlope: 4, Overlap: 0.7}},
+		{"triangular log", 0, 1000, 6, PartitionOptions{Spacing: Logarithmic}},
+		{"gaussian log around zero", -20000, 20000, 7, PartitionOptions{Shape: ShapeGaussian, Spacing: Logarithmic}},
+		{"bell log below zero", -100, -1, 4, PartitionOptions{Shape: ShapeBell, Spacing: Logarithmic, LogBase: 10}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			terms, err := Partition(tc.min, tc.max, tc.n, tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(terms) != tc.n {
+				t.Fatalf("%d terms, want %d", len(terms), tc.n)
+			}
+			peaks := peaksOf(terms)
+			if math.Abs(peaks[0]-tc.min) > 1e-9 || math.Abs(peaks[tc.n-1]-tc.max) > 1e-9 {
+				t.Errorf("peaks %v do not span [%g, %g]", peaks, tc.min, tc.max)
+			}
+			for i := 1; i < tc.n; i++ {
+				if !(peaks[i] > peaks[i-1]) {
+					t.Errorf("peaks %v are not increasing", peaks)
+				}
+			}
+
+			o := tc.opts.Overlap
+			if o == 0 {
+				o = 0.5
+			}
+			for i := 0; i <= 1000; i++ {
+				x := tc.min + (tc.max-tc.min)*float64(i)/1000
+				best := 0.0
+				for _, term := range terms {
+					best = math.Max(best, term.MF.Eval(x))
+				}
+				if best < o-1e-9 {
+					t.Fatalf("membership at %g is %g, below the overlap %g", x, best, o)
+				}
+			}
+		})
+	}
+}
+
+// TestPartitionLogSpacing checks that logarithmic spacing around zero puts
+// the middle term at zero, mirrors the peaks on both sides of a symmetric
+// universe and widens the gaps away from zero, while uniform spacing keeps
+// them equal.
+func TestPartitionLogSpacing(t *testing.T) {
+	terms, err := Partition(-20000, 20000, 7, PartitionOptions{Spacing: Logarithmic, LogBase: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	peaks := peaksOf(terms)
+	if peaks[3] != 0 {
+		t.Errorf("middle peak at %g, want 0", peaks[3])
+	}
+	for i := 1; i <= 3; i++ {
+		if math.Abs(peaks[3+i]+peaks[3-i]) > 1e-9 {
+			t.Errorf("peaks %g and %g are not symmetric about zero", peaks[3-i], peaks[3+i])
+		}
+		if i > 1 && !(peaks[3+i]-peaks[2+i] > peaks[2+i]-peaks[1+i]) {
+			t.Errorf("gaps of %v do not widen away from zero", peaks)
+		}
+	}
+	// With three terms a side the first peak is at (1000^(1/3) - 1) /
+	// (1000 - 1) of the distance to the far end.
+	if want := 20000 * 9.0 / 999; math.Abs(peaks[4]-want) > 1e-9 {
+		t.Errorf("first positive peak at %g, want %g", peaks[4], want)
+	}
+
+	uniform, err := Partition(-20000, 20000, 5, PartitionOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []float64{-20000, -10000, 0, 10000, 20000} {
+		if p := uniform[i].MF.Peak(); p != want {
+			t.Errorf("uniform peak %d at %g, want %g", i+1, p, want)
+		}
+	}
+}
+
+// TestPartitionWidths checks the width of gaussian and bell terms: one width
+// serves both sides, so the term reaches the overlap exactly half way to its
+// farther neighbour and above it half way to the nearer one.
+func TestPartitionWidths(t *testing.T) {
+	const o = 0.3
+	for _, shape := range []Shape{ShapeGaussian, ShapeBell} {
+		terms, err := Partition(0, 100, 4, PartitionOptions{Shape: shape, Spacing: Logarithmic, Overlap: o})
+		if err != nil {
+			t.Fatal(err)
+		}
+		peaks := peaksOf(terms)
+		for i := 1; i < len(terms)-1; i++ {
+			left, right := (peaks[i]-peaks[i-1])/2, (peaks[i+1]-peaks[i])/2
+			far, near := peaks[i]+right, peaks[i]-left
+			if left > right {
+				far, near = near, far
+			}
+			mf := terms[i].MF
+			if mu := mf.Eval(far); math.Abs(mu-o) > 1e-9 {
+				t.Errorf("%s term %d: %g half way to the farther neighbour, want %g", shape, i+1, mu, o)
+			}
+			if mu := mf.Eval(near); mu <= o {
+				t.Errorf("%s term %d: %g half way to the nearer neighbour, want above %g", shape, i+1, mu, o)
+			}
+		}
+		switch mf := terms[0].MF.(type) {
+		case Gaussian:
+			if want := (peaks[1] - peaks[0]) / 2 / math.Sqrt(2*math.Log(1/o)); math.Abs(mf.Sigma-want) > 1e-9 {
+				t.Errorf("first gaussian sigma %g, want %g", mf.Sigma, want)
+			}
+		case Bell:
+			if mf.B != 2 {
+				t.Errorf("bell slope %g, want the default 2", mf.B)
+			}
+		default:
+			t.Errorf("%s partition has %T terms", shape, mf)
+		}
+	}
+}
+
+func TestPartitionErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		min, max float64
+		n        int
+		opts     PartitionOptions
+		want     string
+	}{
+		{"empty universe", 1, 1, 3, PartitionOptions{}, "empty universe [1, 1]"},
+		{"too few terms", 0, 1, 1, PartitionOptions{}, "a partition needs at least 2 terms"},
+		{"names", 0, 1, 3, PartitionOptions{Names: []string{"A", "B"}}, "2 names for 3 terms"},
+		{"overlap", 0, 1, 3, PartitionOptions{Overlap: 1}, "overlap 1 is outside (0, 1)"},
+		{"log base", 0, 1, 3, PartitionOptions{Spacing: Logarithmic, LogBase: 1}, "log base 1 is not above 1"},
+		{"even around zero", -1, 1, 4, PartitionOptions{Spacing: Logarithmic}, "needs an odd number of terms, got 4"},
+		{"shape", 0, 1, 3, PartitionOptions{Shape: Shape(7)}, "unknown shape 7"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Partition(tc.min, tc.max, tc.n, tc.opts)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Partition = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
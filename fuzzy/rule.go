@@ -0,0 +1,165 @@
+package fuzzy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Memberships holds the fuzzified inputs of a controller, indexed by
+// variable name and then by term name.
+type Memberships map[string]map[string]float64
+
+// Antecedent is the IF part of a rule.
+type Antecedent interface {
+	// Degree returns how strongly the antecedent holds for m, combining
+	// clauses with the operators ops.
+	Degree(m Memberships, ops Operators) float64
+	String() string
+}
+
+// Is is the clause "Var IS Term", or "Var IS hedge ... Term" when it has
+// Hedges. Hedges are listed as written: the last one applies first.
+type Is struct {
+	Var    string
+	Term   string
+	Hedges []Hedge
+}
+
+func (is Is) Degree(m Memberships, ops Operators) float64 {
+	mu := m[is.Var][is.Term]
+	for i := len(is.Hedges) - 1; i >= 0; i-- {
+		mu = is.Hedges[i].apply(mu)
+	}
+	return mu
+}
+
+func (is Is) String() string {
+	s := is.Var + " IS "
+	for _, h := range is.Hedges {
+		s += h.String() + " "
+	}
+	return s + is.Term
+}
+
+// And holds when every one of its antecedents holds. Its degree is the
+// t-norm of theirs, by default the minimum.
+type And []Antecedent
+
+func (and And) Degree(m Memberships, ops Operators) float64 {
+	d := 1.0
+	for _, a := range and {
+		d = ops.and(d, a.Degree(m, ops))
+	}
+	return d
+}
+
+func (and And) String() string {
+	parts := make([]string, len(and))
+	for i, a := range and {
+		parts[i] = a.String()
+		if _, ok := a.(Or); ok {
+			parts[i] = "(" + parts[i] + ")"
+		}
+	}
+	return strings.Join(parts, " AND ")
+}
+
+// Or holds when any of its antecedents holds. Its degree is the s-norm of
+// theirs, by default the maximum.
+type Or []Antecedent
+
+func (or Or) Degree(m Memberships, ops Operators) float64 {
+	d := 0.0
+	for _, a := range or {
+		d = ops.or(d, a.Degree(m, ops))
+	}
+	return d
+}
+
+func (or Or) String() string {
+	parts := make([]string, len(or))
+	for i, a := range or {
+		parts[i] = a.String()
+	}
+	return strings.Join(parts, " OR ")
+}
+
+// Not holds when its antecedent does not. Its degree is the complement of
+// that of A.
+type Not struct {
+	A Antecedent
+}
+
+func (not Not) Degree(m Memberships, ops Operators) float64 {
+	return 1 - not.A.Degree(m, ops)
+}
+
+func (not Not) String() string {
+	return fmt.Sprintf("NOT (%s)", not.A)
+}
+
+// Rule is "IF If THEN output IS Then".
+//
+// Weight, in (0, 1], scales the firing strength of the rule, as WITH does in
+// IEC 61131-7, for every defuzzifier. Gain scales its consequent instead: the
+// representative value of the output term, and the term itself, stretched
+// about zero, in the aggregated output set. The importance factors of the
+// original consumers are gains. Zero means unset, that is 1, for both: the
+// definition and FCL readers reject an explicit 0.
+//
+// When TSK is set the rule is a Takagi-Sugeno-Kang rule: its consequent is
+// the value of TSK rather than the output term Then, scaled by Gain.
+type Rule struct {
+	If     Antecedent
+	Then   string
+	TSK    *Linear
+	Weight float64
+	Gain   float64
+}
+
+func (r Rule) weight() float64 {
+	if r.Weight == 0 {
+		return 1
+	}
+	return r.Weight
+}
+
+func (r Rule) gain() float64 {
+	if r.Gain == 0 {
+		return 1
+	}
+	return r.Gain
+}
+
+func (r Rule) String() string {
+	s := fmt.Sprintf("IF %s THEN %s", r.If, r.Then)
+	if r.TSK != nil {
+		s = fmt.Sprintf("IF %s THEN %s", r.If, r.TSK)
+	}
+	if r.Weight != 0 && r.Weight != 1 {
+		s += fmt.Sprintf(" WITH %g", r.Weight)
+	}
+	if r.Gain != 0 && r.Gain != 1 {
+		s += fmt.Sprintf(" GAIN %g", r.Gain)
+	}
+	return s
+}
+
+// RuleTable returns the rules of a two input rule table: the cell table[i][j]
+// gives the output term of "IF row IS rows[i] AND col IS cols[j]". Empty
+// cells produce no rule.
+func RuleTable(row, col string, rows, cols []string, table [][]string) []Rule {
+	var rs []Rule
+	for i, r := range rows {
+		for j, c := range cols {
+			if table[i][j] == "" {
+				continue
+			}
+			rs = append(rs, Rule{
+				If:   And{Is{Var: row, Term: r}, Is{Var: col, Term: c}},
+				Then: table[i][j],
+			})
+		}
+	}
+	return rs
+}
@@ -0,0 +1,151 @@
+package fuzzy
+
+import (
+	"fmt"
+	"math"
+)
+
+// ScaleMode is how an InputScale maps a crisp input into its universe.
+type ScaleMode int
+
+const (
+	ScaleGain    ScaleMode = iota // x · Factor
+	ScalePercent                  // 100 · x / ref
+	ScaleUnit                     // x / ref, clamped to [-1, 1]
+)
+
+var scaleModeNames = map[ScaleMode]string{ScaleGain: "gain", ScalePercent: "percent", ScaleUnit: "unit"}
+
+func (m ScaleMode) String() string { return scaleModeNames[m] }
+
+// Set implements flag.Value.
+func (m *ScaleMode) Set(s string) error {
+	for k, name := range scaleModeNames {
+		if name == s {
+			*m = k
+			return nil
+		}
+	}
+	return fmt.Errorf("fuzzy: unknown scale mode %q", s)
+}
+
+// InputScale normalizes a crisp input before it is fuzzified, so that one
+// rule base can serve different setpoints: with Ref "goal" and ScalePercent,
+// an error of 500 msg/sec against a goal of 25000 enters the controller as 2.
+//
+// The reference ref of ScalePercent and ScaleUnit is the crisp input named
+// Ref, or Factor when Ref is empty.
+type InputScale struct {
+	Mode   ScaleMode
+	Ref    string
+	Factor float64
+}
+
+func (s InputScale) apply(x float64, in map[string]float64) (float64, error) {
+	if s.Mode == ScaleGain {
+		return x * s.Factor, nil
+	}
+
+	ref := s.Factor
+	if s.Ref != "" {
+		var ok bool
+		if ref, ok = in[s.Ref]; !ok {
+			return 0, fmt.Errorf("fuzzy: missing input %q to scale by", s.Ref)
+		}
+	}
+	if ref == 0 {
+		return 0, fmt.Errorf("fuzzy: cannot scale by a zero reference")
+	}
+	if s.Mode == ScalePercent {
+		return 100 * x / ref, nil
+	}
+	return math.Max(-1, math.Min(1, x/ref)), nil
+}
+
+// ScaleInputs returns in with the Scales of c applied, as Fire sees it, or in
+// itself when c has none.
+func (c *Controller) ScaleInputs(in map[string]float64) (map[string]float64, error) {
+	if len(c.Scales) == 0 {
+		return in, nil
+	}
+	scaled := make(map[string]float64, len(in))
+	for name, x := range in {
+		scaled[name] = x
+	}
+	for name, s := range c.Scales {
+		x, ok := in[name]
+		if !ok {
+			continue // reported by Fuzzify if name is an input
+		}
+		var err error
+		if scaled[name], err = s.apply(x, in); err != nil {
+			return nil, err
+		}
+	}
+	return scaled, nil
+}
+
+func (c *Controller) outputGain() float64 {
+	if c.OutputGain == 0 {
+		return 1
+	}
+	return c.OutputGain
+}
+
+// Rescale multiplies the universe of v and every coordinate of its terms by
+// k > 0, e.g. to move terms tuned in msg/sec to a percentage of a goal.
+func (v *Variable) Rescale(k float64) error {
+	if !(k > 0) {
+		return fmt.Errorf("fuzzy: cannot rescale %s by %g", v.Name, k)
+	}
+	terms := make([]Term, len(v.Terms))
+	for i, t := range v.Terms {
+		mf, err := rescale(t.MF, k)
+		if err != nil {
+			return fmt.Errorf("fuzzy: %s: term %s: %w", v.Name, t.Name, err)
+		}
+		terms[i] = Term{Name: t.Name, MF: mf}
+		if t.Lower != nil {
+			if terms[i].Lower, err = rescale(t.Lower, k); err != nil {
+				return fmt.Errorf("fuzzy: %s: term %s: %w", v.Name, t.Name, err)
+			}
+		}
+	}
+	v.Min, v.Max, v.Terms = v.Min*k, v.Max*k, terms
+	return nil
+}
+
+// rescale returns mf stretched about zero by k > 0, the membership function
+// whose degree at k x is that of mf at x. Rescale uses it for the terms of
+// a variable and WriteFCL for the output terms of rules with a gain.
+func rescale(mf MembershipFunction, k float64) (MembershipFunction, error) {
+	switch mf := mf.(type) {
+	case Triangular:
+		return Triangular{mf.A * k, mf.B * k, mf.C * k}, nil
+	case Trapezoidal:
+		return Trapezoidal{mf.A * k, mf.B * k, mf.C * k, mf.D * k}, nil
+	case Gaussian:
+		return Gaussian{mf.Mu * k, mf.Sigma * k}, nil
+	case Bell:
+		return Bell{mf.A * k, mf.B, mf.C * k}, nil
+	case Sigmoid:
+		return Sigmoid{mf.A / k, mf.C * k}, nil
+	case DiffSigmoid:
+		return DiffSigmoid{mf.A1 / k, mf.C1 * k, mf.A2 / k, mf.C2 * k}, nil
+	case Pi:
+		return Pi{mf.A * k, mf.B * k, mf.C * k, mf.D * k}, nil
+	case SShape:
+		return SShape{mf.A * k, mf.B * k}, nil
+	case ZShape:
+		return ZShape{mf.A * k, mf.B * k}, nil
+	case Singleton:
+		return Singleton{mf.X * k}, nil
+	case PiecewiseLinear:
+		pts := make(PiecewiseLinear, len(mf))
+		for i, p := range mf {
+			pts[i] = Point{p.X * k, p.Y}
+		}
+		return pts, nil
+	}
+	return nil, fmt.Errorf("unsupported membership function %T", mf)
+}
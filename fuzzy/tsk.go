@@ -0,0 +1,46 @@
+package fuzzy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Linear is a Takagi-Sugeno-Kang rule consequent: Const plus the sum of
+// Coeffs[name] times the crisp input called name. With no coefficients it is
+// a zero order (constant) consequent.
+type Linear struct {
+	Const  float64            `json:"const" yaml:"const"`
+	Coeffs map[string]float64 `json:"coeffs,omitempty" yaml:"coeffs,omitempty"`
+}
+
+// Eval returns the value of the consequent for the crisp inputs in.
+func (l Linear) Eval(in map[string]float64) (float64, error) {
+	v := l.Const
+	for name, k := range l.Coeffs {
+		x, ok := in[name]
+		if !ok {
+			return 0, fmt.Errorf("fuzzy: missing input %q", name)
+		}
+		v += k * x
+	}
+	return v, nil
+}
+
+// inputs returns the names of the crisp inputs l weighs, sorted.
+func (l Linear) inputs() []string {
+	names := make([]string, 0, len(l.Coeffs))
+	for name := range l.Coeffs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (l Linear) String() string {
+	parts := []string{fmt.Sprintf("%g", l.Const)}
+	for _, name := range l.inputs() {
+		parts = append(parts, fmt.Sprintf("%g*%s", l.Coeffs[name], name))
+	}
+	return strings.Join(parts, " + ")
+}
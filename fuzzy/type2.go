@@ -0,0 +1,130 @@
+package fuzzy
+
+import (
+	"math"
+	"sort"
+)
+
+// Type2 reports whether some input term of c is interval type-2.
+func (c *Controller) Type2() bool {
+	for _, v := range c.Inputs {
+		for _, t := range v.Terms {
+			if t.Lower != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fuzzifyLower is Fuzzify with the lower membership functions. The inputs
+// have been checked by Fuzzify already.
+func (c *Controller) fuzzifyLower(in map[string]float64) Memberships {
+	m := make(Memberships, len(c.Inputs))
+	for _, v := range c.Inputs {
+		m[v.Name] = v.FuzzifyLower(in[v.Name])
+	}
+	return m
+}
+
+// interval returns the lowest and the highest degree of a over the
+// memberships between lower and upper. Hedges, t-norms and s-norms never
+// decrease with their arguments, so the bounds come from the bounds of the
+// clauses, swapped by every complement.
+func interval(a Antecedent, lower, upper Memberships, ops Operators) (lo, hi float64) {
+	switch a := a.(type) {
+	case Is:
+		lo, hi = lower[a.Var][a.Term], upper[a.Var][a.Term]
+		for i := len(a.Hedges) - 1; i >= 0; i-- {
+			lo, hi = a.Hedges[i].apply(lo), a.Hedges[i].apply(hi)
+			if a.Hedges[i] == Complement {
+				lo, hi = hi, lo
+			}
+		}
+		return lo, hi
+	case And:
+		lo, hi = 1, 1
+		for _, b := range a {
+			l, h := interval(b, lower, upper, ops)
+			lo, hi = ops.and(lo, l), ops.and(hi, h)
+		}
+		return lo, hi
+	case Or:
+		for _, b := range a {
+			l, h := interval(b, lower, upper, ops)
+			lo, hi = ops.or(lo, l), ops.or(hi, h)
+		}
+		return lo, hi
+	case Not:
+		l, h := interval(a.A, lower, upper, ops)
+		return 1 - h, 1 - l
+	}
+	lo, hi = a.Degree(lower, ops), a.Degree(upper, ops)
+	return math.Min(lo, hi), math.Max(lo, hi)
+}
+
+// typeReduce returns the middle of the interval of weighted averages the
+// firing intervals of o allow, and records the interval.
+func (o *Output) typeReduce() (float64, bool) {
+	type point struct{ y, lo, hi float64 }
+	var ps []point
+	for _, a := range o.Activations {
+		if a.Strength > 0 {
+			w := a.Rule.weight()
+			ps = append(ps, point{a.Value, a.Lower * w, a.Strength * w})
+		}
+	}
+	if len(ps) == 0 {
+		return 0, false
+	}
+	sort.Slice(ps, func(i, j int) bool { return ps[i].y < ps[j].y })
+
+	ys := make([]float64, len(ps))
+	lo, hi := make([]float64, len(ps)), make([]float64, len(ps))
+	for i, p := range ps {
+		ys[i], lo[i], hi[i] = p.y, p.lo, p.hi
+	}
+	// The smallest average weighs the small values with their upper
+	// strengths and the large ones with their lower strengths; the largest
+	// average does the opposite.
+	o.yl = karnikMendel(ys, hi, lo)
+	o.yr = karnikMendel(ys, lo, hi)
+	return (o.yl + o.yr) / 2, true
+}
+
+// karnikMendel returns the extreme weighted average of the sorted values ys
+// with weights between lo and hi that takes the weights of left up to some
+// switch point and those of right after it, iterating the switch point
+// from the average of the middle weights until it stays put.
+func karnikMendel(ys, left, right []float64) float64 {
+	w := make([]float64, len(ys))
+	for i := range w {
+		w[i] = (left[i] + right[i]) / 2
+	}
+	y := average(ys, w)
+	for iter := 0; iter < len(ys)+1; iter++ {
+		// The switch point k is the last value at or below y.
+		k := sort.Search(len(ys), func(i int) bool { return ys[i] > y }) - 1
+		for i := range w {
+			w[i] = right[i]
+			if i <= k {
+				w[i] = left[i]
+			}
+		}
+		next := average(ys, w)
+		if next == y || math.IsNaN(next) {
+			break
+		}
+		y = next
+	}
+	return y
+}
+
+func average(ys, w []float64) float64 {
+	numerator, denominator := 0.0, 0.0
+	for i, y := range ys {
+		numerator += w[i] * y
+		denominator += w[i]
+	}
+	return numerator / denominator
+}
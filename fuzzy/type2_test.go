@@ -0,0 +1,101 @@
+package fuzzy
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// extremeAverages returns the smallest and the largest weighted average of
+// ys over every choice of each weight at lo[i] or hi[i], the corners where
+// the extremes lie.
+func extremeAverages(ys, lo, hi []float64) (min, max float64) {
+	min, max = math.Inf(1), math.Inf(-1)
+	w := make([]float64, len(ys))
+	for mask := 0; mask < 1<<len(ys); mask++ {
+		for i := range w {
+			w[i] = lo[i]
+			if mask&(1<<i) != 0 {
+				w[i] = hi[i]
+			}
+		}
+		if y := average(ys, w); !math.IsNaN(y) {
+			min, max = math.Min(min, y), math.Max(max, y)
+		}
+	}
+	return min, max
+}
+
+func TestKarnikMendel(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for n := 1; n <= 8; n++ {
+		for trial := 0; trial < 50; trial++ {
+			ys, lo, hi := make([]float64, n), make([]float64, n), make([]float64, n)
+			for i := range ys {
+				ys[i] = r.Float64()*20 - 10
+				hi[i] = 0.1 + 0.9*r.Float64()
+				lo[i] = hi[i] * r.Float64()
+			}
+			sort.Float64s(ys)
+			if trial%10 == 0 {
+				lo[r.Intn(n)] = 0 // a rule whose lower strength is zero
+			}
+
+			wantL, wantR := extremeAverages(ys, lo, hi)
+			yl, yr := karnikMendel(ys, hi, lo), karnikMendel(ys, lo, hi)
+			if math.Abs(yl-wantL) > 1e-9 || math.Abs(yr-wantR) > 1e-9 {
+				t.Fatalf("ys %v, weights from %v to %v: Karnik-Mendel gives [%g, %g], brute force [%g, %g]",
+					ys, lo, hi, yl, yr, wantL, wantR)
+			}
+		}
+	}
+}
+
+// TestKarnikMendelSwitchPoints checks a small example by hand: values 0, 1
+// and 2 with weights in [0.5, 1]. The smallest average weighs 0 fully and
+// 1 and 2 by half, switching after the first value: (0 + 0.5 + 1) / 2; the
+// largest weighs 0 and 1 by half and 2 fully: (0 + 0.5 + 2) / 2.
+func TestKarnikMendelSwitchPoints(t *testing.T) {
+	ys, lo, hi := []float64{0, 1, 2}, []float64{0.5, 0.5, 0.5}, []float64{1, 1, 1}
+	if yl := karnikMendel(ys, hi, lo); yl != 0.75 {
+		t.Errorf("smallest average = %g, want 0.75", yl)
+	}
+	if yr := karnikMendel(ys, lo, hi); yr != 1.25 {
+		t.Errorf("largest average = %g, want 1.25", yr)
+	}
+}
+
+// TestType2CollapsesToType1 checks that type-2 terms whose lower membership
+// functions equal their upper ones give the type-1 output.
+func TestType2CollapsesToType1(t *testing.T) {
+	type1 := benchController()
+	type2 := benchController()
+	for i, term := range type2.Inputs[0].Terms {
+		type2.Inputs[0].Terms[i].Lower = term.MF
+	}
+	type2.Rules[3].Weight = 0.5
+	type1.Rules[3].Weight = 0.5
+	if !type2.Type2() {
+		t.Fatal("controller is not type-2")
+	}
+
+	for e := -20000.0; e <= 20000; e += 500 {
+		in := map[string]float64{"error": e}
+		want, err := type1.Evaluate(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := type2.Fire(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := WeightedAverage{}.Defuzzify(out)
+		if !ok {
+			t.Fatalf("error %g: no rule fired", e)
+		}
+		if math.Abs(got-want) > 1e-9 || math.Abs(out.yl-out.yr) > 1e-9 {
+			t.Errorf("error %g: type-2 output %g in [%g, %g], want the type-1 %g", e, got, out.yl, out.yr, want)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package fuzzy
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// coverageSamples is the number of points of each input universe checked by
+// Validate for coverage.
+const coverageSamples = 1001
+
+// Validate checks that c is well formed: every variable has a non-empty
+// universe and well-formed terms, every rule refers to existing variables
+// and terms, TSK coefficients weigh input variables, Crisp inputs or scale
+// references, TSK rules and type-2 terms are defuzzified by the weighted
+// average, and every point of every input universe belongs to some term.
+// All problems found are reported together.
+func (c *Controller) Validate() error {
+	var errs []error
+	if len(c.Inputs) == 0 {
+		errs = append(errs, errors.New("no input variables"))
+	}
+	if c.Output == nil {
+		errs = append(errs, errors.New("no output variable"))
+	}
+	if len(c.Rules) == 0 {
+		errs = append(errs, errors.New("no rules"))
+	}
+
+	for _, v := range c.Inputs {
+		errs = append(errs, v.validate()...)
+		if gap, ok := v.gap(); ok {
+			errs = append(errs, fmt.Errorf("%s: no term covers %g", v.Name, gap))
+		}
+	}
+	if c.Output != nil {
+		errs = append(errs, c.Output.validate()...)
+		for _, t := range c.Output.Terms {
+			if t.Lower != nil {
+				errs = append(errs, fmt.Errorf("%s: term %s: output terms cannot be type-2", c.Output.Name, t.Name))
+			}
+		}
+	}
+
+	crisp := map[string]bool{}
+	for _, v := range c.Inputs {
+		crisp[v.Name] = true
+	}
+	for _, name := range c.Crisp {
+		crisp[name] = true
+	}
+	for _, s := range c.Scales {
+		if s.Ref != "" {
+			crisp[s.Ref] = true
+		}
+	}
+	tsk := false
+	for i, r := range c.Rules {
+		if r.TSK != nil {
+			tsk = true
+			for _, name := range r.TSK.inputs() {
+				if !crisp[name] {
+					errs = append(errs, fmt.Errorf("rule %d: TSK coefficient of unknown input %s", i+1, name))
+				}
+			}
+		}
+		if r.If == nil {
+			errs = append(errs, fmt.Errorf("rule %d: no antecedent", i+1))
+			continue
+		}
+		for _, is := range clauses(r.If) {
+			v := c.input(is.Var)
+			if v == nil {
+				errs = append(errs, fmt.Errorf("rule %d: unknown input variable %s", i+1, is.Var))
+			} else if _, ok := v.Term(is.Term); !ok {
+				errs = append(errs, fmt.Errorf("rule %d: unknown term %s of %s", i+1, is.Term, is.Var))
+			}
+		}
+		if r.TSK == nil && c.Output != nil {
+			if _, ok := c.Output.Term(r.Then); !ok {
+				errs = append(errs, fmt.Errorf("rule %d: unknown term %s of %s", i+1, r.Then, c.Output.Name))
+			}
+		}
+		if !(r.Weight >= 0 && r.Weight <= 1) {
+			errs = append(errs, fmt.Errorf("rule %d: weight %g is outside (0, 1]", i+1, r.Weight))
+		}
+		if !(r.Gain >= 0) || math.IsInf(r.Gain, 0) {
+			errs = append(errs, fmt.Errorf("rule %d: gain %g is negative or infinite", i+1, r.Gain))
+		}
+	}
+
+	if d := c.defuzzifier(); d != (WeightedAverage{}) {
+		if tsk {
+			errs = append(errs, fmt.Errorf("TSK rules need the weighted-average defuzzifier, not %s", d))
+		}
+		if c.Type2() {
+			errs = append(errs, fmt.Errorf("type-2 terms need the weighted-average defuzzifier, not %s", d))
+		}
+		// A sampled output set misses a singleton between its samples.
+		if c.Output != nil {
+			for _, t := range c.Output.Terms {
+				if _, ok := t.MF.(Singleton); ok {
+					errs = append(errs, fmt.Errorf("%s: term %s: singleton output terms need the weighted-average defuzzifier, not %s", c.Output.Name, t.Name, d))
+				}
+			}
+		}
+	}
+
+	for name, s := range c.Scales {
+		if c.input(name) == nil {
+			errs = append(errs, fmt.Errorf("scale of unknown input variable %s", name))
+		}
+		if s.Ref == "" && s.Mode != ScaleGain && s.Factor == 0 {
+			errs = append(errs, fmt.Errorf("%s: %s scale needs a reference input or a non-zero factor", name, s.Mode))
+		}
+	}
+	if math.IsNaN(c.OutputGain) || math.IsInf(c.OutputGain, 0) {
+		errs = append(errs, fmt.Errorf("output gain %g is not finite", c.OutputGain))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("fuzzy: invalid controller %s:\n%w", c.Name, err)
+	}
+	return nil
+}
+
+func (v *Variable) validate() []error {
+	var errs []error
+	if !(v.Min < v.Max) {
+		errs = append(errs, fmt.Errorf("%s: empty universe [%g, %g]", v.Name, v.Min, v.Max))
+	}
+	if len(v.Terms) == 0 {
+		errs = append(errs, fmt.Errorf("%s: no terms", v.Name))
+	}
+	seen := map[string]bool{}
+	for _, t := range v.Terms {
+		if seen[t.Name] {
+			errs = append(errs, fmt.Errorf("%s: duplicate term %s", v.Name, t.Name))
+		}
+		seen[t.Name] = true
+		if t.MF == nil {
+			errs = append(errs, fmt.Errorf("%s: term %s has no membership function", v.Name, t.Name))
+		} else if err := validateMF(t.MF); err != nil {
+			errs = append(errs, fmt.Errorf("%s: term %s: %w", v.Name, t.Name, err))
+		}
+		if t.Lower != nil {
+			if err := validateMF(t.Lower); err != nil {
+				errs = append(errs, fmt.Errorf("%s: term %s: lower: %w", v.Name, t.Name, err))
+			} else if x, ok := v.above(t); ok {
+				errs = append(errs, fmt.Errorf("%s: term %s: lower membership above the upper one at %g", v.Name, t.Name, x))
+			}
+		}
+	}
+	return errs
+}
+
+// above returns the first sampled point of the universe of v where the
+// lower membership function of t exceeds its upper one.
+func (v *Variable) above(t Term) (float64, bool) {
+	if t.MF == nil || !(v.Min < v.Max) {
+		return 0, false
+	}
+	step := (v.Max - v.Min) / (coverageSamples - 1)
+	for i := 0; i < coverageSamples; i++ {
+		x := v.Min + float64(i)*step
+		if t.Lower.Eval(x) > t.MF.Eval(x)+1e-12 {
+			return x, true
+		}
+	}
+	return 0, false
+}
+
+// gap returns the first sampled point of the universe of v where every term
+// has zero membership. A bound of the universe where the support of a term
+// ends, such as the foot of the outer triangles of the triangular consumer,
+// counts as covered.
+func (v *Variable) gap() (float64, bool) {
+	if !(v.Min < v.Max) || len(v.Terms) == 0 {
+		return 0, false
+	}
+	step := (v.Max - v.Min) / (coverageSamples - 1)
+	for i := 0; i < coverageSamples; i++ {
+		x := v.Min + float64(i)*step
+		if i == coverageSamples-1 {
+			x = v.Max
+		}
+		covered := false
+		for _, t := range v.Terms {
+			if t.MF == nil {
+				continue
+			}
+			lo, hi := t.MF.Support()
+			if t.MF.Eval(x) > 0 || (i == 0 && lo == x) || (i == coverageSamples-1 && hi == x) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return x, true
+		}
+	}
+	return 0, false
+}
+
+// ordered reports whether xs is non-decreasing and spans a non-empty range.
+func ordered(xs ...float64) bool {
+	for i := 1; i < len(xs); i++ {
+		if !(xs[i-1] <= xs[i]) {
+			return false
+		}
+	}
+	return xs[0] < xs[len(xs)-1]
+}
+
+func validateMF(mf MembershipFunction) error {
+	switch mf := mf.(type) {
+	case Triangular:
+		if !ordered(mf.A, mf.B, mf.C) {
+			return fmt.Errorf("triangular needs a <= b <= c and a < c, got %g %g %g", mf.A, mf.B, mf.C)
+		}
+	case Trapezoidal:
+		if !ordered(mf.A, mf.B, mf.C, mf.D) {
+			return fmt.Errorf("trapezoidal needs a <= b <= c <= d and a < d, got %g %g %g %g", mf.A, mf.B, mf.C, mf.D)
+		}
+	case Gaussian:
+		if !(mf.Sigma > 0) || math.IsNaN(mf.Mu) {
+			return fmt.Errorf("gaussian needs sigma > 0, got %g", mf.Sigma)
+		}
+	case Bell:
+		if !(mf.A > 0 && mf.B > 0) || math.IsNaN(mf.C) {
+			return fmt.Errorf("bell needs a > 0 and b > 0, got %g %g", mf.A, mf.B)
+		}
+	case Sigmoid:
+		if mf.A == 0 || math.IsNaN(mf.A) || math.IsNaN(mf.C) {
+			return fmt.Errorf("sigmoid needs a non-zero slope, got %g", mf.A)
+		}
+	case DiffSigmoid:
+		if mf.A1 == 0 || mf.A2 == 0 || math.IsNaN(mf.A1+mf.A2+mf.C1+mf.C2) {
+			return fmt.Errorf("difference of sigmoids needs non-zero slopes, got %g %g", mf.A1, mf.A2)
+		}
+	case Pi:
+		if !ordered(mf.A, mf.B, mf.C, mf.D) {
+			return fmt.Errorf("pi needs a <= b <= c <= d and a < d, got %g %g %g %g", mf.A, mf.B, mf.C, mf.D)
+		}
+	case SShape:
+		if !ordered(mf.A, mf.B) {
+			return fmt.Errorf("S shape needs a < b, got %g %g", mf.A, mf.B)
+		}
+	case ZShape:
+		if !ordered(mf.A, mf.B) {
+			return fmt.Errorf("Z shape needs a < b, got %g %g", mf.A, mf.B)
+		}
+	case Singleton:
+		if math.IsNaN(mf.X) || math.IsInf(mf.X, 0) {
+			return fmt.Errorf("singleton needs a finite value, got %g", mf.X)
+		}
+	case PiecewiseLinear:
+		if len(mf) == 0 {
+			return errors.New("point list is empty")
+		}
+		for i, p := range mf {
+			if i > 0 && !(mf[i-1].X < p.X) {
+				return fmt.Errorf("points must have increasing x, got %g after %g", p.X, mf[i-1].X)
+			}
+			if !(p.Y >= 0 && p.Y <= 1) {
+				return fmt.Errorf("point (%g, %g) is outside [0, 1]", p.X, p.Y)
+			}
+		}
+	}
+	return nil
+}
+
+// clauses returns every "Var IS Term" clause of a.
+func clauses(a Antecedent) []Is {
+	switch a := a.(type) {
+	case Is:
+		return []Is{a}
+	case And:
+		var cs []Is
+		for _, sub := range a {
+			cs = append(cs, clauses(sub)...)
+		}
+		return cs
+	case Or:
+		var cs []Is
+		for _, sub := range a {
+			cs = append(cs, clauses(sub)...)
+		}
+		return cs
+	case Not:
+		return clauses(a.A)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package fuzzy
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	if err := weightController(0, 0).Validate(); err != nil {
+		t.Fatalf("base controller: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		modify func(c *Controller)
+		want   string
+	}{
+		{"no inputs", func(c *Controller) { c.Inputs, c.Rules = nil, nil }, "no input variables"},
+		{"no output", func(c *Controller) { c.Output = nil }, "no output variable"},
+		{"no rules", func(c *Controller) { c.Rules = nil }, "no rules"},
+		{"empty universe", func(c *Controller) { c.Inputs[0].Max = 0 }, "x: empty universe [0, 0]"},
+		{"no terms", func(c *Controller) { c.Output.Terms, c.Rules = nil, nil }, "y: no terms"},
+		{"duplicate term", func(c *Controller) {
+			c.Output.Terms = append(c.Output.Terms, c.Output.Terms[0])
+		}, "y: duplicate term LOW"},
+		{"no membership function", func(c *Controller) { c.Output.Terms[0].MF = nil }, "y: term LOW has no membership function"},
+		{"triangular", func(c *Controller) { c.Output.Terms[0].MF = Triangular{1, 0, -1} }, "triangular needs a <= b <= c"},
+		{"trapezoidal", func(c *Controller) { c.Inputs[0].Terms[0].MF = Trapezoidal{2, 1, 0, -1} }, "trapezoidal needs"},
+		{"gaussian", func(c *Controller) { c.Output.Terms[0].MF = Gaussian{0, 0} }, "gaussian needs sigma > 0"},
+		{"bell", func(c *Controller) { c.Output.Terms[0].MF = Bell{0, 1, 0} }, "bell needs a > 0 and b > 0"},
+		{"sigmoid", func(c *Controller) { c.Output.Terms[0].MF = Sigmoid{0, 0} }, "sigmoid needs a non-zero slope"},
+		{"dsigmoid", func(c *Controller) { c.Output.Terms[0].MF = DiffSigmoid{1, 0, 0, 1} }, "difference of sigmoids needs"},
+		{"pi", func(c *Controller) { c.Output.Terms[0].MF = Pi{0, 0, 0, 0} }, "pi needs"},
+		{"s", func(c *Controller) { c.Output.Terms[0].MF = SShape{1, 0} }, "S shape needs a < b"},
+		{"z", func(c *Controller) { c.Output.Terms[0].MF = ZShape{1, 1} }, "Z shape needs a < b"},
+		{"singleton", func(c *Controller) { c.Output.Terms[0].MF = Singleton{math.Inf(1)} }, "singleton needs a finite value"},
+		{"empty points", func(c *Controller) { c.Output.Terms[0].MF = PiecewiseLinear{} }, "point list is empty"},
+		{"unordered points", func(c *Controller) {
+			c.Output.Terms[0].MF = PiecewiseLinear{{1, 0}, {0, 1}}
+		}, "points must have increasing x"},
+		{"point above 1", func(c *Controller) { c.Output.Terms[0].MF = PiecewiseLinear{{0, 2}} }, "point (0, 2) is outside [0, 1]"},
+		{"invalid lower", func(c *Controller) {
+			c.Inputs[0].Terms[0].Lower = Trapezoidal{1, 0, 0, 0}
+		}, "x: term ALL: lower: trapezoidal needs"},
+		{"lower above upper", func(c *Controller) {
+			c.Inputs[0].Terms[0].MF = Triangular{-1, 0.5, 2}
+			c.Inputs[0].Terms[0].Lower = Trapezoidal{-1, 0, 1, 2}
+		}, "x: term ALL: lower membership above the upper one at 0"},
+		{"gap", func(c *Controller) { c.Inputs[0].Terms[0].MF = Triangular{-1, 0, 0.5} }, "x: no term covers 0.5"},
+		{"type-2 output", func(c *Controller) {
+			c.Output.Terms[0].Lower = Triangular{-1, 0, 1}
+		}, "y: term LOW: output terms cannot be type-2"},
+		{"no antecedent", func(c *Controller) { c.Rules[0].If = nil }, "rule 1: no antecedent"},
+		{"unknown input", func(c *Controller) { c.Rules[0].If = Is{Var: "z", Term: "ALL"} }, "rule 1: unknown input variable z"},
+		{"unknown input term", func(c *Controller) {
+			c.Rules[1].If = And{Is{Var: "x", Term: "ALL"}, Not{Is{Var: "x", Term: "NONE"}}}
+		}, "rule 2: unknown term NONE of x"},
+		{"unknown output term", func(c *Controller) { c.Rules[1].Then = "MID" }, "rule 2: unknown term MID of y"},
+		{"weight", func(c *Controller) { c.Rules[0].Weight = 2 }, "rule 1: weight 2 is outside (0, 1]"},
+		{"gain", func(c *Controller) { c.Rules[0].Gain = math.Inf(1) }, "rule 1: gain +Inf is negative or infinite"},
+		{"TSK coefficient", func(c *Controller) {
+			c.Rules[0].TSK = &Linear{Coeffs: map[string]float64{"x": 1, "prefetch": 1}}
+		}, "rule 1: TSK coefficient of unknown input prefetch"},
+		{"TSK defuzzifier", func(c *Controller) {
+			c.Rules[0].TSK = &Linear{Const: 1}
+			c.Defuzzifier = Centroid{}
+		}, "TSK rules need the weighted-average defuzzifier, not centroid"},
+		{"type-2 defuzzifier", func(c *Controller) {
+			c.Inputs[0].Terms[0].Lower = Trapezoidal{-0.5, 0.5, 0.5, 1.5}
+			c.Defuzzifier = MeanOfMaximum{}
+		}, "type-2 terms need the weighted-average defuzzifier, not mom"},
+		{"singleton output", func(c *Controller) {
+			c.Output.Terms[1].MF = Singleton{10}
+			c.Defuzzifier = Centroid{}
+		}, "y: term HIGH: singleton output terms need the weighted-average defuzzifier, not centroid"},
+		{"scale of unknown input", func(c *Controller) {
+			c.Scales = map[string]InputScale{"z": {Mode: ScaleGain, Factor: 2}}
+		}, "scale of unknown input variable z"},
+		{"scale reference", func(c *Controller) {
+			c.Scales = map[string]InputScale{"x": {Mode: ScalePercent}}
+		}, "x: percent scale needs a reference input or a non-zero factor"},
+		{"output gain", func(c *Controller) { c.OutputGain = math.NaN() }, "output gain NaN is not finite"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := weightController(0, 0)
+			tc.modify(c)
+			err := c.Validate()
+			if err == nil {
+				t.Fatalf("Validate accepted the controller, want %q", tc.want)
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Validate = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+// TestValidateBounds checks that a term whose support ends on a bound of the
+// universe covers that bound, while a universe reaching past it has a gap.
+func TestValidateBounds(t *testing.T) {
+	c := weightController(0, 0)
+	c.Inputs[0].Terms[0].MF = Triangular{0, 0.5, 1}
+	if err := c.Validate(); err != nil {
+		t.Error(err)
+	}
+	c.Inputs[0].Max = 2
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "x: no term covers") {
+		t.Errorf("Validate = %v, want a gap past 1", err)
+	}
+}
+
+// TestValidateCrisp checks that TSK coefficients may weigh the crisp inputs
+// the controller declares and the references of its scales.
+func TestValidateCrisp(t *testing.T) {
+	c := weightController(0, 0)
+	c.Rules[0].TSK = &Linear{Coeffs: map[string]float64{"x": 1, "prefetch": 1, "goal": 1}}
+	c.Crisp = []string{"prefetch"}
+	c.Scales = map[string]InputScale{"x": {Mode: ScaleUnit, Ref: "goal"}}
+	if err := c.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestValidateSingletonOutput checks that singleton output terms, which the
+// sampled output set misses between samples, are accepted with the weighted
+// average, where they weigh their value like any other peak.
+func TestValidateSingletonOutput(t *testing.T) {
+	c := weightController(0.5, 0)
+	c.Output.Terms[1].MF = Singleton{9.95}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	u, err := c.Evaluate(map[string]float64{"x": 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 0.5 * 9.95 / 1.5; math.Abs(u-want) > 1e-9 {
+		t.Errorf("output = %g, want %g", u, want)
+	}
+	for _, d := range []Defuzzifier{Centroid{}, Bisector{}, MeanOfMaximum{}} {
+		c.Defuzzifier = d
+		if err := c.Validate(); err == nil {
+			t.Errorf("%s: Validate accepted a singleton output term", d)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package fuzzy
+
+// Term is a named fuzzy set of a linguistic variable, e.g. "LP" (Large
+// Positive) of the error variable.
+//
+// A term with a Lower membership function is interval type-2: MF is then
+// its upper membership function, Lower never exceeds it, and the band
+// between the two, the footprint of uncertainty, holds every membership
+// degree the term may have.
+type Term struct {
+	Name  string
+	MF    MembershipFunction
+	Lower MembershipFunction
+}
+
+// Variable is a linguistic variable defined over the universe [Min, Max].
+type Variable struct {
+	Name     string
+	Min, Max float64
+	Terms    []Term
+}
+
+// Term returns the term called name and whether it exists.
+func (v *Variable) Term(name string) (Term, bool) {
+	for _, t := range v.Terms {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Term{}, false
+}
+
+// Fuzzify returns the membership degree of x in every term of v.
+func (v *Variable) Fuzzify(x float64) map[string]float64 {
+	fuzzy := make(map[string]float64, len(v.Terms))
+	for _, t := range v.Terms {
+		fuzzy[t.Name] = t.MF.Eval(x)
+	}
+	return fuzzy
+}
+
+// FuzzifyLower is Fuzzify with the lower membership functions of the
+// interval type-2 terms of v. Type-1 terms give the same degree as in
+// Fuzzify.
+func (v *Variable) FuzzifyLower(x float64) map[string]float64 {
+	fuzzy := make(map[string]float64, len(v.Terms))
+	for _, t := range v.Terms {
+		mf := t.MF
+		if t.Lower != nil {
+			mf = t.Lower
+		}
+		fuzzy[t.Name] = mf.Eval(x)
+	}
+	return fuzzy
+}
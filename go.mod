@@ -3,6 +3,12 @@ module rabbitMQ
 go 1.22.1
 
 require (
-	github.com/rabbitmq/amqp091-go v1.9.0 // indirect
-	github.com/streadway/amqp v1.1.0 // indirect
+	github.com/streadway/amqp v1.1.0
+	golang.org/x/image v0.18.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/kr/pretty v0.1.0 // indirect
+	gopkg.in/check.v1 v1.0.0-20180628173108-788fd7840127 // indirect
 )
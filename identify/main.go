@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"math"
+	"sort"
+
+	"rabbitMQ/internal/cli"
+	"rabbitMQ/plant"
+	"rabbitMQ/plot"
+)
+
+func failOnError(err error, msg string) {
+	if err != nil {
+		log.Fatalf("%s: %s", msg, err)
+	}
+}
+
+// identify fits a plant model to measurements: the static curve to the
+// prefetch count and rate of an experiment, the Prefetch Count X Rate
+// spreadsheet in docs by default or a CSV export of it, and with -trace the
+// time constant to a recorded run, such as a CSV file of the simulate
+// command. It writes the model as JSON for the -plant flag of simulate and
+// tune, and a chart of the measurements against the fitted curve.
+func main() {
+	data := flag.String("data", "docs/Prefetch Count X Rate - First experiment.xlsx", ".xlsx or CSV file of prefetch (or PC) and rate measurements")
+	tracePath := flag.String("trace", "", ".xlsx or CSV file of time, prefetch and rate per control window to fit the time constant to")
+	tau := flag.Float64("tau", plant.FirstExperiment.Tau, "time constant in seconds when there is no -trace")
+	out := flag.String("o", "plant.json", "output plant model JSON")
+	chart := flag.String("chart", "plant", "output path prefix of the .svg and .png chart (empty = no chart)")
+	flag.Parse()
+
+	ms, err := plant.ReadMeasurements(*data)
+	failOnError(err, "Failed to read the measurements")
+	model, rmse, err := plant.FitStatic(ms)
+	failOnError(err, "Failed to fit the static curve")
+	log.Printf("Static curve of %d measurements: rmax %.1f msg/sec, k %.3f, RMSE %.1f msg/sec", len(ms), model.RMax, model.K, rmse)
+
+	if *tracePath != "" {
+		tr, err := plant.ReadTrace(*tracePath)
+		failOnError(err, "Failed to read the trace")
+		model, rmse, err = plant.FitTau(model, tr)
+		failOnError(err, "Failed to fit the time constant")
+		log.Printf("Dynamics of %d windows: tau %.2f s, RMSE %.1f msg/sec", len(tr.Time), model.Tau, rmse)
+	} else {
+		model.Tau = *tau
+	}
+	failOnError(model.Validate(), "Failed to identify the plant")
+	failOnError(plant.Save(*out, model), "Failed to write "+*out)
+	log.Printf("Wrote %s", *out)
+
+	if *chart == "" {
+		return
+	}
+	sort.Slice(ms, func(i, j int) bool { return ms[i].Prefetch < ms[j].Prefetch })
+	var px, py, fx, fy []float64
+	maxP, maxR := 0.0, 0.0
+	for _, m := range ms {
+		px = append(px, m.Prefetch)
+		py = append(py, m.Rate)
+		maxP, maxR = math.Max(maxP, m.Prefetch), math.Max(maxR, m.Rate)
+	}
+	for i := 0; i <= 200; i++ {
+		p := maxP * float64(i) / 200
+		fx = append(fx, p)
+		fy = append(fy, model.Static(p))
+	}
+	c := &plot.Chart{
+		Title:  "Prefetch Count X Rate",
+		XLabel: "Prefetch count",
+		YLabel: "Rate (msg/sec)",
+		Series: []plot.Series{{Name: "measured", X: px, Y: py}, {Name: "fitted", X: fx, Y: fy, Dashed: true}},
+		YMin:   0,
+		YMax:   math.Max(maxR, model.RMax) * 1.1,
+	}
+	failOnError(cli.WriteFile(*chart+".svg", func(f io.Writer) error { return c.SVG(f) }), "Failed to write")
+	failOnError(cli.WriteFile(*chart+".png", func(f io.Writer) error { return c.PNG(f) }), "Failed to write")
+}
@@ -0,0 +1,48 @@
+// Package cli holds the helpers the commands share to write their CSV,
+// JSON, SVG and PNG output files.
+package cli
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"strconv"
+)
+
+// WriteFile creates path, calls write with it and closes it, logging the
+// path once it is written. When any step fails it removes the partial file
+// and returns the error, naming the path.
+func WriteFile(path string, write func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = write(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	log.Printf("Wrote %s", path)
+	return nil
+}
+
+// FormatFloat formats x for a CSV cell, in the fewest digits that read back
+// as x.
+func FormatFloat(x float64) string {
+	return strconv.FormatFloat(x, 'g', -1, 64)
+}
+
+// Max returns the largest of xs, or 0 if they are all negative or there are
+// none, e.g. for the top of a chart whose y axis starts at 0.
+func Max(xs []float64) float64 {
+	m := 0.0
+	for _, x := range xs {
+		m = math.Max(m, x)
+	}
+	return m
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	ok := filepath.Join(dir, "ok.txt")
+	if err := WriteFile(ok, func(w io.Writer) error {
+		_, err := io.WriteString(w, "done\n")
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := os.ReadFile(ok); err != nil || string(b) != "done\n" {
+		t.Errorf("wrote %q, %v", b, err)
+	}
+
+	// A failed write leaves no partial file behind.
+	failed := filepath.Join(dir, "failed.txt")
+	errWrite := errors.New("no more room")
+	err := WriteFile(failed, func(w io.Writer) error {
+		io.WriteString(w, "part")
+		return errWrite
+	})
+	if !errors.Is(err, errWrite) {
+		t.Errorf("WriteFile = %v, want %v", err, errWrite)
+	}
+	if _, err := os.Stat(failed); !os.IsNotExist(err) {
+		t.Errorf("partial file left behind: %v", err)
+	}
+}
+
+func TestFormatFloatAndMax(t *testing.T) {
+	a, b := 0.1, 0.2
+	if s := FormatFloat(a + b); s != "0.30000000000000004" {
+		t.Errorf("FormatFloat(0.1 + 0.2) = %s, want all the digits that read back", s)
+	}
+	if m := Max([]float64{-3, 2.5, 1}); m != 2.5 {
+		t.Errorf("Max = %g, want 2.5", m)
+	}
+	if m := Max([]float64{-3, -1}); m != 0 {
+		t.Errorf("Max of negatives = %g, want 0", m)
+	}
+}
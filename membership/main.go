@@ -0,0 +1,108 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+
+	"rabbitMQ/controllers"
+	"rabbitMQ/fuzzy"
+	"rabbitMQ/internal/cli"
+	"rabbitMQ/plot"
+)
+
+func failOnError(err error, msg string) {
+	if err != nil {
+		log.Fatalf("%s: %s", msg, err)
+	}
+}
+
+// chart plots the terms of v over its universe, widened by a twentieth on
+// each side so that the universe bounds show as marks. The lower membership
+// functions of type-2 terms are dashed, bounding their footprint of
+// uncertainty.
+func chart(title string, v *fuzzy.Variable, samples int) *plot.Chart {
+	pad := (v.Max - v.Min) / 20
+	lo, hi := v.Min-pad, v.Max+pad
+	c := &plot.Chart{
+		Title:  title,
+		XLabel: v.Name,
+		YLabel: "Membership",
+		XMin:   lo,
+		XMax:   hi,
+		YMin:   0,
+		YMax:   1.1,
+		Marks: []plot.Mark{
+			{X: v.Min, Text: fmt.Sprintf("min %g", v.Min)},
+			{X: v.Max, Text: fmt.Sprintf("max %g", v.Max)},
+		},
+	}
+	for _, t := range v.Terms {
+		s := plot.Series{Name: t.Name}
+		for i := 0; i < samples; i++ {
+			x := lo + float64(i)*(hi-lo)/float64(samples-1)
+			s.X = append(s.X, x)
+			s.Y = append(s.Y, t.MF.Eval(x))
+		}
+		c.Series = append(c.Series, s)
+		if t.Lower != nil {
+			lower := plot.Series{Dashed: true}
+			for _, x := range s.X {
+				lower.X = append(lower.X, x)
+				lower.Y = append(lower.Y, t.Lower.Eval(x))
+			}
+			c.Series = append(c.Series, lower)
+		}
+
+		peak := math.Max(v.Min, math.Min(v.Max, t.MF.Peak()))
+		c.Labels = append(c.Labels, plot.Label{X: peak, Y: t.MF.Eval(peak), Text: t.Name})
+	}
+	return c
+}
+
+// membership renders the terms of every linguistic variable of the
+// consumers' controllers, one chart per variable, as <variant>-<variable>
+// .svg and .png files in -o. The parameters of every term are listed on
+// standard output, including the sigmas the gaussian controller derives.
+// With -config it plots the controller in that file instead, as
+// <file name>-<variable>.
+func main() {
+	variantName := flag.String("variant", "", "built-in controller to plot: gaussian, triangular or bell (default all; with -config, the file is the only controller)")
+	samples := flag.Int("samples", 801, "points each universe is drawn with")
+	dir := flag.String("o", ".", "directory to write the charts to")
+	controllerFlags := controllers.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	variants, err := controllerFlags.Variants(*variantName)
+	failOnError(err, "Invalid -variant")
+	if *samples < 2 {
+		log.Fatalf("-samples must be at least 2")
+	}
+	failOnError(os.MkdirAll(*dir, 0o755), "Failed to create "+*dir)
+
+	for _, variant := range variants {
+		c, err := controllerFlags.Controller(variant)
+		failOnError(err, "Failed to build the fuzzy controller")
+
+		for _, v := range append(append([]*fuzzy.Variable{}, c.Inputs...), c.Output) {
+			if len(v.Terms) == 0 {
+				continue
+			}
+			for _, t := range v.Terms {
+				fmt.Printf("%s\t%s\t%s\t%+v\n", variant.Name, v.Name, t.Name, t.MF)
+				if t.Lower != nil {
+					fmt.Printf("%s\t%s\t%s\tlower %+v\n", variant.Name, v.Name, t.Name, t.Lower)
+				}
+			}
+
+			ch := chart(fmt.Sprintf("%s - %s", variant.Name, v.Name), v, *samples)
+			prefix := filepath.Join(*dir, variant.Name+"-"+v.Name)
+			failOnError(cli.WriteFile(prefix+".svg", func(f io.Writer) error { return ch.SVG(f) }), "Failed to write")
+			failOnError(cli.WriteFile(prefix+".png", func(f io.Writer) error { return ch.PNG(f) }), "Failed to write")
+		}
+	}
+}
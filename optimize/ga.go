@@ -0,0 +1,134 @@
+package optimize
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// GAOptions tune GA.
+type GAOptions struct {
+	// Population is the number of parameter sets of every generation. Zero
+	// means 30.
+	Population int
+	// Generations is the number of generations bred. Zero means 50.
+	Generations int
+	// Crossover is the probability that two parents are blended rather
+	// than copied. Zero means 0.9.
+	Crossover float64
+	// Mutation is the probability that a parameter of a child is
+	// perturbed. Zero means one over the number of parameters.
+	Mutation float64
+	// Elite is the number of best parameter sets carried over unchanged to
+	// the next generation. Zero means 1.
+	Elite int
+	// Rand is the source of randomness. Nil means a source seeded with 1.
+	Rand *rand.Rand
+	// Progress, when set, is called after every generation.
+	Progress func(Progress)
+}
+
+// GA minimizes p with a real coded genetic algorithm: tournament
+// selection, blend crossover, gaussian mutation and elitism. x0, when not
+// nil, seeds the first generation, so the result is never worse than it.
+func GA(p Problem, x0 []float64, opts GAOptions) (*Result, error) {
+	if err := p.check(x0); err != nil {
+		return nil, err
+	}
+	if opts.Population == 0 {
+		opts.Population = 30
+	}
+	if opts.Generations == 0 {
+		opts.Generations = 50
+	}
+	if opts.Crossover == 0 {
+		opts.Crossover = 0.9
+	}
+	if opts.Mutation == 0 {
+		opts.Mutation = 1 / float64(len(p.Min))
+	}
+	if opts.Elite == 0 {
+		opts.Elite = 1
+	}
+	if opts.Elite > opts.Population {
+		opts.Elite = opts.Population
+	}
+	r := opts.Rand
+	if r == nil {
+		r = rand.New(rand.NewSource(1))
+	}
+
+	pop := p.population(opts.Population, x0, r)
+	costs := make([]float64, len(pop))
+	for i, x := range pop {
+		costs[i] = p.cost(x)
+	}
+
+	res := &Result{}
+	for gen := 0; ; gen++ {
+		// Sort the generation by cost, best first.
+		order := make([]int, len(pop))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(i, j int) bool { return costs[order[i]] < costs[order[j]] })
+		sorted, sortedCosts := make([][]float64, len(pop)), make([]float64, len(pop))
+		for i, k := range order {
+			sorted[i], sortedCosts[i] = pop[k], costs[k]
+		}
+		pop, costs = sorted, sortedCosts
+
+		res.Best, res.Cost = append([]float64(nil), pop[0]...), costs[0]
+		prog := Progress{Iteration: gen, Best: costs[0], Mean: mean(costs)}
+		res.History = append(res.History, prog)
+		if opts.Progress != nil {
+			opts.Progress(prog)
+		}
+		if gen == opts.Generations {
+			return res, nil
+		}
+
+		next := append([][]float64(nil), pop[:opts.Elite]...)
+		nextCosts := append([]float64(nil), costs[:opts.Elite]...)
+		for len(next) < len(pop) {
+			a, b := pop[tournament(costs, r)], pop[tournament(costs, r)]
+			child := append([]float64(nil), a...)
+			if r.Float64() < opts.Crossover {
+				blend(child, a, b, r)
+			}
+			for i := range child {
+				if r.Float64() < opts.Mutation {
+					child[i] += r.NormFloat64() * 0.1 * (p.Max[i] - p.Min[i])
+				}
+			}
+			p.clamp(child)
+			next = append(next, child)
+			nextCosts = append(nextCosts, p.cost(child))
+		}
+		pop, costs = next, nextCosts
+	}
+}
+
+// tournament returns the index of the cheapest of three random members.
+func tournament(costs []float64, r *rand.Rand) int {
+	best := r.Intn(len(costs))
+	for i := 0; i < 2; i++ {
+		if k := r.Intn(len(costs)); costs[k] < costs[best] {
+			best = k
+		}
+	}
+	return best
+}
+
+// blend sets every parameter of child to a random point of the interval
+// spanned by a and b, widened by half its length on each side (BLX-0.5).
+func blend(child, a, b []float64, r *rand.Rand) {
+	const alpha = 0.5
+	for i := range child {
+		lo, hi := a[i], b[i]
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		d := hi - lo
+		child[i] = lo - alpha*d + r.Float64()*(1+2*alpha)*d
+	}
+}
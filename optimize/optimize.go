@@ -0,0 +1,99 @@
+// Package optimize minimizes cost functions of bounded real parameters with
+// population based methods that need no gradient: a genetic algorithm and
+// particle swarm optimization.
+package optimize
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+)
+
+// Problem is a cost to minimize over the box [Min, Max]. Cost may return
+// +Inf for parameters it rejects.
+type Problem struct {
+	Min, Max []float64
+	Cost     func(x []float64) float64
+}
+
+// Result is the best parameters found and their cost. History is the state
+// of the search after every generation or iteration, for convergence plots.
+type Result struct {
+	Best    []float64
+	Cost    float64
+	History []Progress
+}
+
+// Progress is the state of a search after one generation or iteration.
+type Progress struct {
+	Iteration int
+	Best      float64 // lowest cost so far
+	Mean      float64 // mean finite cost of the population
+}
+
+func (p Problem) check(x0 []float64) error {
+	if len(p.Min) != len(p.Max) || len(p.Min) == 0 {
+		return errors.New("optimize: bounds of different or zero length")
+	}
+	if x0 != nil && len(x0) != len(p.Min) {
+		return errors.New("optimize: initial parameters and bounds differ in length")
+	}
+	for i := range p.Min {
+		if !(p.Min[i] <= p.Max[i]) {
+			return errors.New("optimize: empty bounds")
+		}
+	}
+	return nil
+}
+
+func (p Problem) clamp(x []float64) {
+	for i := range x {
+		x[i] = math.Max(p.Min[i], math.Min(p.Max[i], x[i]))
+	}
+}
+
+func (p Problem) random(r *rand.Rand) []float64 {
+	x := make([]float64, len(p.Min))
+	for i := range x {
+		x[i] = p.Min[i] + r.Float64()*(p.Max[i]-p.Min[i])
+	}
+	return x
+}
+
+// population returns n parameter sets, the first of them a copy of x0 when
+// it is not nil and the others random.
+func (p Problem) population(n int, x0 []float64, r *rand.Rand) [][]float64 {
+	pop := make([][]float64, n)
+	for i := range pop {
+		if i == 0 && x0 != nil {
+			pop[i] = append([]float64(nil), x0...)
+			p.clamp(pop[i])
+			continue
+		}
+		pop[i] = p.random(r)
+	}
+	return pop
+}
+
+func mean(costs []float64) float64 {
+	sum, n := 0.0, 0
+	for _, c := range costs {
+		if !math.IsInf(c, 0) && !math.IsNaN(c) {
+			sum += c
+			n++
+		}
+	}
+	if n == 0 {
+		return math.Inf(1)
+	}
+	return sum / float64(n)
+}
+
+// cost is p.Cost with NaN taken as +Inf, so comparisons stay meaningful.
+func (p Problem) cost(x []float64) float64 {
+	c := p.Cost(x)
+	if math.IsNaN(c) {
+		return math.Inf(1)
+	}
+	return c
+}
@@ -0,0 +1,109 @@
+package optimize
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// methods runs GA and PSO with a seeded source and enough generations or
+// iterations to converge on small problems.
+var methods = []struct {
+	name string
+	run  func(p Problem, x0 []float64, seed int64) (*Result, error)
+}{
+	{"GA", func(p Problem, x0 []float64, seed int64) (*Result, error) {
+		return GA(p, x0, GAOptions{Generations: 200, Rand: rand.New(rand.NewSource(seed))})
+	}},
+	{"PSO", func(p Problem, x0 []float64, seed int64) (*Result, error) {
+		return PSO(p, x0, PSOOptions{Iterations: 200, Rand: rand.New(rand.NewSource(seed))})
+	}},
+}
+
+// quadratic is a bowl over [-10, 10]^3 with its minimum of 0 at (1, -2, 3).
+func quadratic() Problem {
+	centre := []float64{1, -2, 3}
+	return Problem{
+		Min: []float64{-10, -10, -10},
+		Max: []float64{10, 10, 10},
+		Cost: func(x []float64) float64 {
+			sum := 0.0
+			for i, c := range centre {
+				sum += (x[i] - c) * (x[i] - c)
+			}
+			return sum
+		},
+	}
+}
+
+func TestConvergesOnQuadratic(t *testing.T) {
+	for _, m := range methods {
+		for seed := int64(1); seed <= 3; seed++ {
+			res, err := m.run(quadratic(), nil, seed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Cost > 1e-3 {
+				t.Errorf("%s, seed %d: cost %g at %v, want the minimum 0 at [1 -2 3]", m.name, seed, res.Cost, res.Best)
+			}
+			if c := quadratic().Cost(res.Best); c != res.Cost {
+				t.Errorf("%s, seed %d: reported cost %g, the best parameters cost %g", m.name, seed, res.Cost, c)
+			}
+			for i := 1; i < len(res.History); i++ {
+				if res.History[i].Best > res.History[i-1].Best {
+					t.Errorf("%s, seed %d: best cost rose from %g to %g at iteration %d",
+						m.name, seed, res.History[i-1].Best, res.History[i].Best, res.History[i].Iteration)
+					break
+				}
+			}
+		}
+	}
+}
+
+// TestNeverWorseThanX0 uses a cost that is 0 only exactly at x0, which no
+// random or bred parameters hit, and 1 elsewhere: the search must return
+// x0 itself.
+func TestNeverWorseThanX0(t *testing.T) {
+	x0 := []float64{0.123456789, -4.5}
+	p := Problem{
+		Min: []float64{-5, -5},
+		Max: []float64{5, 5},
+		Cost: func(x []float64) float64 {
+			if x[0] == x0[0] && x[1] == x0[1] {
+				return 0
+			}
+			return 1 + math.Abs(x[0]) // some slope to chase away from x0
+		},
+	}
+	for _, m := range methods {
+		for seed := int64(1); seed <= 3; seed++ {
+			res, err := m.run(p, x0, seed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Cost != 0 || res.Best[0] != x0[0] || res.Best[1] != x0[1] {
+				t.Errorf("%s, seed %d: best %v with cost %g, want x0 %v with cost 0", m.name, seed, res.Best, res.Cost, x0)
+			}
+		}
+	}
+}
+
+func TestProblemErrors(t *testing.T) {
+	cost := func([]float64) float64 { return 0 }
+	for _, tc := range []struct {
+		name string
+		p    Problem
+		x0   []float64
+	}{
+		{"no bounds", Problem{Cost: cost}, nil},
+		{"bounds of different lengths", Problem{Min: []float64{0}, Max: []float64{1, 1}, Cost: cost}, nil},
+		{"empty bounds", Problem{Min: []float64{1}, Max: []float64{0}, Cost: cost}, nil},
+		{"x0 length", Problem{Min: []float64{0}, Max: []float64{1}, Cost: cost}, []float64{0, 0}},
+	} {
+		for _, m := range methods {
+			if _, err := m.run(tc.p, tc.x0, 1); err == nil {
+				t.Errorf("%s: %s accepted the problem", tc.name, m.name)
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package optimize
+
+import (
+	"math"
+	"math/rand"
+)
+
+// PSOOptions tune PSO.
+type PSOOptions struct {
+	// Particles is the size of the swarm. Zero means 30.
+	Particles int
+	// Iterations is the number of moves of the swarm. Zero means 50.
+	Iterations int
+	// Inertia, Cognitive and Social weigh the previous velocity of a
+	// particle, the pull towards its own best position and the pull towards
+	// the best position of the swarm. Zero means 0.7, 1.5 and 1.5.
+	Inertia, Cognitive, Social float64
+	// Rand is the source of randomness. Nil means a source seeded with 1.
+	Rand *rand.Rand
+	// Progress, when set, is called after every iteration.
+	Progress func(Progress)
+}
+
+// PSO minimizes p with a global best particle swarm. Velocities are limited
+// to a fifth of the bounds per iteration, and particles stop at the bounds.
+// x0, when not nil, is the starting position of the first particle, so the
+// result is never worse than it.
+func PSO(p Problem, x0 []float64, opts PSOOptions) (*Result, error) {
+	if err := p.check(x0); err != nil {
+		return nil, err
+	}
+	if opts.Particles == 0 {
+		opts.Particles = 30
+	}
+	if opts.Iterations == 0 {
+		opts.Iterations = 50
+	}
+	if opts.Inertia == 0 {
+		opts.Inertia = 0.7
+	}
+	if opts.Cognitive == 0 {
+		opts.Cognitive = 1.5
+	}
+	if opts.Social == 0 {
+		opts.Social = 1.5
+	}
+	r := opts.Rand
+	if r == nil {
+		r = rand.New(rand.NewSource(1))
+	}
+
+	n := len(p.Min)
+	pos := p.population(opts.Particles, x0, r)
+	vel := make([][]float64, len(pos))
+	best := make([][]float64, len(pos))
+	bestCost := make([]float64, len(pos))
+	costs := make([]float64, len(pos))
+	res := &Result{Cost: math.Inf(1)}
+	for i, x := range pos {
+		vel[i] = make([]float64, n)
+		best[i] = append([]float64(nil), x...)
+		bestCost[i] = p.cost(x)
+		costs[i] = bestCost[i]
+		if bestCost[i] < res.Cost || res.Best == nil {
+			res.Best, res.Cost = append([]float64(nil), x...), bestCost[i]
+		}
+	}
+
+	for it := 0; ; it++ {
+		prog := Progress{Iteration: it, Best: res.Cost, Mean: mean(costs)}
+		res.History = append(res.History, prog)
+		if opts.Progress != nil {
+			opts.Progress(prog)
+		}
+		if it == opts.Iterations {
+			return res, nil
+		}
+
+		for i, x := range pos {
+			for k := range x {
+				vmax := 0.2 * (p.Max[k] - p.Min[k])
+				v := opts.Inertia*vel[i][k] +
+					opts.Cognitive*r.Float64()*(best[i][k]-x[k]) +
+					opts.Social*r.Float64()*(res.Best[k]-x[k])
+				vel[i][k] = math.Max(-vmax, math.Min(vmax, v))
+				x[k] += vel[i][k]
+			}
+			p.clamp(x)
+			costs[i] = p.cost(x)
+			if costs[i] < bestCost[i] {
+				best[i], bestCost[i] = append(best[i][:0], x...), costs[i]
+				if costs[i] < res.Cost {
+					res.Best, res.Cost = append([]float64(nil), x...), costs[i]
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,323 @@
+package plant
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Measurement is the steady message rate measured at a prefetch count.
+type Measurement struct {
+	Prefetch, Rate float64
+}
+
+// Trace is a recorded run, one entry per control window: the time at the
+// end of the window, in seconds, the prefetch count it ran with and the
+// average rate measured over it. The CSV files of the simulate command are
+// traces.
+type Trace struct {
+	Time, Prefetch, Rate []float64
+}
+
+// Column names accepted in measurement files, case-insensitively.
+var (
+	prefetchColumn = []string{"prefetch", "pc", "prefetch count"}
+	rateColumn     = []string{"rate"}
+	timeColumn     = []string{"time"}
+)
+
+// ReadMeasurements reads prefetch count and rate measurements from an .xlsx
+// workbook, of which the first sheet is read, or from a CSV file, such as
+// the spreadsheet of the first experiment or a CSV export of it. The
+// measurements are the rows below the first row that names a prefetch (or
+// PC) column and a rate column; other columns are ignored.
+func ReadMeasurements(file string) ([]Measurement, error) {
+	cols, err := readColumns(file, prefetchColumn, rateColumn)
+	if err != nil {
+		return nil, err
+	}
+	ms := make([]Measurement, len(cols[0]))
+	for i := range ms {
+		ms[i] = Measurement{Prefetch: cols[0][i], Rate: cols[1][i]}
+	}
+	return ms, nil
+}
+
+// ReadTrace reads a trace from a file with time, prefetch and rate columns,
+// in the formats ReadMeasurements reads.
+func ReadTrace(file string) (*Trace, error) {
+	cols, err := readColumns(file, timeColumn, prefetchColumn, rateColumn)
+	if err != nil {
+		return nil, err
+	}
+	return &Trace{Time: cols[0], Prefetch: cols[1], Rate: cols[2]}, nil
+}
+
+// readColumns returns the numbers of the named columns of file, each named
+// by any of its aliases.
+func readColumns(file string, names ...[]string) ([][]float64, error) {
+	var rows [][]string
+	var err error
+	if strings.EqualFold(filepath.Ext(file), ".xlsx") {
+		rows, err = readXLSX(file)
+	} else {
+		rows, err = readCSV(file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("plant: %s: %w", file, err)
+	}
+	cols, err := columns(rows, names...)
+	if err != nil {
+		return nil, fmt.Errorf("plant: %s: %w", file, err)
+	}
+	return cols, nil
+}
+
+func readCSV(file string) ([][]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	return r.ReadAll()
+}
+
+// columns finds the header row naming every column in names and returns
+// the numbers below it. Rows with all those cells empty are skipped.
+func columns(rows [][]string, names ...[]string) ([][]float64, error) {
+	for h, header := range rows {
+		idx := make([]int, len(names))
+		found := 0
+		for k, aliases := range names {
+			idx[k] = -1
+			for i, cell := range header {
+				if matches(cell, aliases) {
+					idx[k] = i
+					found++
+					break
+				}
+			}
+		}
+		if found < len(names) {
+			continue
+		}
+
+		cols := make([][]float64, len(names))
+		for n, row := range rows[h+1:] {
+			cells := make([]string, len(idx))
+			empty := true
+			for k, i := range idx {
+				if i < len(row) {
+					cells[k] = strings.TrimSpace(row[i])
+				}
+				empty = empty && cells[k] == ""
+			}
+			if empty {
+				continue
+			}
+			for k, cell := range cells {
+				x, err := strconv.ParseFloat(cell, 64)
+				if err != nil {
+					return nil, fmt.Errorf("row %d: %s: %w", h+n+2, names[k][0], err)
+				}
+				cols[k] = append(cols[k], x)
+			}
+		}
+		if len(cols[0]) == 0 {
+			return nil, errors.New("no measurements below the header")
+		}
+		return cols, nil
+	}
+
+	want := make([]string, len(names))
+	for k, aliases := range names {
+		want[k] = aliases[0]
+	}
+	return nil, fmt.Errorf("no header row with %s columns", strings.Join(want, ", "))
+}
+
+func matches(cell string, aliases []string) bool {
+	cell = strings.TrimSpace(cell)
+	for _, a := range aliases {
+		if strings.EqualFold(cell, a) {
+			return true
+		}
+	}
+	return false
+}
+
+// readXLSX returns the cells of the first sheet of an .xlsx workbook as
+// text, by row and column; rows and columns missing from the sheet are
+// empty.
+func readXLSX(file string) ([][]string, error) {
+	z, err := zip.OpenReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer z.Close()
+
+	sheet, err := firstSheet(&z.Reader)
+	if err != nil {
+		return nil, err
+	}
+	var strs []string
+	if f := zipFile(&z.Reader, "xl/sharedStrings.xml"); f != nil {
+		if strs, err = sharedStrings(f); err != nil {
+			return nil, err
+		}
+	}
+
+	f := zipFile(&z.Reader, sheet)
+	if f == nil {
+		return nil, fmt.Errorf("no sheet %s", sheet)
+	}
+	var ws struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := decodeXML(f, &ws); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	for _, r := range ws.Rows {
+		for _, c := range r.Cells {
+			col, row, err := cellRef(c.Ref)
+			if err != nil {
+				return nil, err
+			}
+			text := c.Value
+			switch c.Type {
+			case "s":
+				i, err := strconv.Atoi(c.Value)
+				if err != nil || i < 0 || i >= len(strs) {
+					return nil, fmt.Errorf("cell %s: bad shared string %q", c.Ref, c.Value)
+				}
+				text = strs[i]
+			case "inlineStr":
+				text = c.Inline
+			}
+			for len(rows) <= row {
+				rows = append(rows, nil)
+			}
+			for len(rows[row]) <= col {
+				rows[row] = append(rows[row], "")
+			}
+			rows[row][col] = text
+		}
+	}
+	return rows, nil
+}
+
+// firstSheet returns the path in the archive of the first sheet of the
+// workbook.
+func firstSheet(z *zip.Reader) (string, error) {
+	const fallback = "xl/worksheets/sheet1.xml"
+	wbFile, relsFile := zipFile(z, "xl/workbook.xml"), zipFile(z, "xl/_rels/workbook.xml.rels")
+	if wbFile == nil || relsFile == nil {
+		return fallback, nil
+	}
+	var wb struct {
+		Sheets []struct {
+			ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	var rels struct {
+		Rels []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodeXML(wbFile, &wb); err != nil {
+		return "", err
+	}
+	if err := decodeXML(relsFile, &rels); err != nil {
+		return "", err
+	}
+	if len(wb.Sheets) == 0 {
+		return "", errors.New("workbook has no sheets")
+	}
+	for _, r := range rels.Rels {
+		if r.ID == wb.Sheets[0].ID {
+			if strings.HasPrefix(r.Target, "/") {
+				return strings.TrimPrefix(r.Target, "/"), nil
+			}
+			return path.Join("xl", r.Target), nil
+		}
+	}
+	return fallback, nil
+}
+
+func sharedStrings(f *zip.File) ([]string, error) {
+	var sst struct {
+		Items []struct {
+			Text string `xml:"t"`
+			Runs []struct {
+				Text string `xml:"t"`
+			} `xml:"r"`
+		} `xml:"si"`
+	}
+	if err := decodeXML(f, &sst); err != nil {
+		return nil, err
+	}
+	strs := make([]string, len(sst.Items))
+	for i, si := range sst.Items {
+		strs[i] = si.Text
+		for _, r := range si.Runs {
+			strs[i] += r.Text
+		}
+	}
+	return strs, nil
+}
+
+func zipFile(z *zip.Reader, name string) *zip.File {
+	for _, f := range z.File {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func decodeXML(f *zip.File, v any) error {
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if err := xml.NewDecoder(r).Decode(v); err != nil && err != io.EOF {
+		return fmt.Errorf("%s: %w", f.Name, err)
+	}
+	return nil
+}
+
+// cellRef returns the zero based column and row of a cell reference such
+// as "B12".
+func cellRef(ref string) (col, row int, err error) {
+	i := 0
+	for i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z' {
+		col = col*26 + int(ref[i]-'A'+1)
+		i++
+	}
+	row, err = strconv.Atoi(ref[i:])
+	if i == 0 || err != nil || row < 1 {
+		return 0, 0, fmt.Errorf("bad cell reference %q", ref)
+	}
+	return col - 1, row - 1, nil
+}
@@ -0,0 +1,85 @@
+package plant
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadMeasurementsCSV(t *testing.T) {
+	ms, err := ReadMeasurements(filepath.Join("testdata", "measurements.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 16 rows below the PC header, and a blank one that is skipped.
+	if len(ms) != 16 {
+		t.Fatalf("read %d measurements, want 16", len(ms))
+	}
+	if ms[0] != (Measurement{Prefetch: 1, Rate: 1715.7}) || ms[15].Prefetch != 60 {
+		t.Errorf("read %v ... %v", ms[0], ms[15])
+	}
+}
+
+// TestReadMeasurementsXLSX reads the workbook of the first experiment, whose
+// fit is FirstExperiment.
+func TestReadMeasurementsXLSX(t *testing.T) {
+	ms, err := ReadMeasurements(filepath.Join("..", "docs", "Prefetch Count X Rate - First experiment.xlsx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ms) < 2 {
+		t.Fatalf("read %d measurements", len(ms))
+	}
+	m, _, err := FitStatic(ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(m.RMax-FirstExperiment.RMax) > 0.1 || math.Abs(m.K-FirstExperiment.K) > 0.001 {
+		t.Errorf("fit %+v, want FirstExperiment %+v", m, FirstExperiment)
+	}
+}
+
+func TestReadColumnsErrors(t *testing.T) {
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		name, csv, want string
+	}{
+		{"no header", "prefetch,speed\n1,2\n", "no header row with prefetch, rate columns"},
+		{"no rows", "Prefetch Count,Rate\n,\n", "no measurements below the header"},
+		{"not a number", "prefetch,rate\n1,2\n2,fast\n", "row 3: rate: strconv.ParseFloat"},
+	} {
+		file := filepath.Join(dir, strings.ReplaceAll(tc.name, " ", "_")+".csv")
+		if err := os.WriteFile(file, []byte(tc.csv), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := ReadMeasurements(file)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: ReadMeasurements = %v, want %q", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestCellRef(t *testing.T) {
+	for _, tc := range []struct {
+		ref      string
+		col, row int
+	}{
+		{"A1", 0, 0},
+		{"B12", 1, 11},
+		{"Z3", 25, 2},
+		{"AA1", 26, 0},
+		{"AB10", 27, 9},
+	} {
+		col, row, err := cellRef(tc.ref)
+		if err != nil || col != tc.col || row != tc.row {
+			t.Errorf("cellRef(%q) = %d, %d, %v, want %d, %d", tc.ref, col, row, err, tc.col, tc.row)
+		}
+	}
+	for _, ref := range []string{"", "12", "A", "A0", "a1"} {
+		if _, _, err := cellRef(ref); err == nil {
+			t.Errorf("cellRef(%q) accepted", ref)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package plant
+
+import (
+	"errors"
+	"math"
+)
+
+// FitStatic fits the static curve of a model to steady-state measurements
+// by least squares and returns it, with a zero Tau, and the root mean
+// square error of the fit in msg/sec. For a given K the best RMax has a
+// closed form, so only K is searched, over a log scale from a thousandth to
+// a hundred times the largest prefetch count measured.
+func FitStatic(ms []Measurement) (Model, float64, error) {
+	maxP := 0.0
+	for _, m := range ms {
+		if !(m.Prefetch > 0) || m.Rate < 0 {
+			return Model{}, 0, errors.New("plant: measurements need positive prefetch counts and rates not negative")
+		}
+		maxP = math.Max(maxP, m.Prefetch)
+	}
+	if len(ms) < 2 {
+		return Model{}, 0, errors.New("plant: at least two measurements are needed")
+	}
+
+	fit := func(k float64) (Model, float64) {
+		sxr, sxx := 0.0, 0.0
+		for _, m := range ms {
+			x := m.Prefetch / (m.Prefetch + k)
+			sxr += x * m.Rate
+			sxx += x * x
+		}
+		model := Model{RMax: sxr / sxx, K: k}
+		sse := 0.0
+		for _, m := range ms {
+			d := model.Static(m.Prefetch) - m.Rate
+			sse += d * d
+		}
+		return model, sse
+	}
+	logK := minimize(func(lk float64) float64 {
+		_, sse := fit(math.Exp(lk))
+		return sse
+	}, math.Log(maxP/1000), math.Log(maxP*100))
+	model, sse := fit(math.Exp(logK))
+	if !(model.RMax > 0) {
+		return Model{}, 0, errors.New("plant: measurements show no rate")
+	}
+	return model, math.Sqrt(sse / float64(len(ms))), nil
+}
+
+// FitTau fits the time constant of m to a trace, keeping the static curve,
+// and returns m with it and the root mean square error in msg/sec of the
+// window averages it predicts. The model starts at the first rate of the
+// trace and follows its prefetch counts; Tau is searched on a log scale
+// from a hundredth to a hundred times the longest window.
+func FitTau(m Model, tr *Trace) (Model, float64, error) {
+	n := len(tr.Time)
+	if len(tr.Prefetch) != n || len(tr.Rate) != n {
+		return m, 0, errors.New("plant: trace columns differ in length")
+	}
+	if n < 3 {
+		return m, 0, errors.New("plant: at least three windows are needed")
+	}
+	maxDT := 0.0
+	for i := 1; i < n; i++ {
+		dt := tr.Time[i] - tr.Time[i-1]
+		if !(dt > 0) {
+			return m, 0, errors.New("plant: trace times must increase")
+		}
+		maxDT = math.Max(maxDT, dt)
+	}
+
+	sse := func(tau float64) float64 {
+		mt := m
+		mt.Tau = tau
+		rate, sse := tr.Rate[0], 0.0
+		for i := 1; i < n; i++ {
+			var mean float64
+			rate, mean = mt.Advance(rate, tr.Prefetch[i], tr.Time[i]-tr.Time[i-1])
+			d := mean - tr.Rate[i]
+			sse += d * d
+		}
+		return sse
+	}
+	m.Tau = math.Exp(minimize(func(lt float64) float64 { return sse(math.Exp(lt)) },
+		math.Log(maxDT/100), math.Log(maxDT*100)))
+	return m, math.Sqrt(sse(m.Tau) / float64(n-1)), nil
+}
+
+// minimize returns the x in [lo, hi] that minimizes f: the best of a grid,
+// refined by golden section search between its neighbours.
+func minimize(f func(float64) float64, lo, hi float64) float64 {
+	const points = 200
+	h := (hi - lo) / points
+	best, bestF := lo, f(lo)
+	for i := 1; i <= points; i++ {
+		if x := lo + float64(i)*h; f(x) < bestF {
+			best, bestF = x, f(x)
+		}
+	}
+
+	a, b := math.Max(lo, best-h), math.Min(hi, best+h)
+	phi := (math.Sqrt(5) - 1) / 2
+	c, d := b-phi*(b-a), a+phi*(b-a)
+	fc, fd := f(c), f(d)
+	for i := 0; i < 60; i++ {
+		if fc < fd {
+			b, d, fd = d, c, fc
+			c = b - phi*(b-a)
+			fc = f(c)
+		} else {
+			a, c, fc = c, d, fd
+			d = a + phi*(b-a)
+			fd = f(d)
+		}
+	}
+	if x := (a + b) / 2; f(x) < bestF {
+		return x
+	}
+	return best
+}
@@ -0,0 +1,81 @@
+package plant
+
+import (
+	"math"
+	"math/rand"
+	"path/filepath"
+	"testing"
+)
+
+// TestFitStatic fits the synthetic curve of testdata, 12000 · p / (p + 6)
+// msg/sec with 2% noise.
+func TestFitStatic(t *testing.T) {
+	ms, err := ReadMeasurements(filepath.Join("testdata", "measurements.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, rmse, err := FitStatic(ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(m.RMax-12000) > 0.05*12000 || math.Abs(m.K-6) > 0.1*6 || m.Tau != 0 {
+		t.Errorf("fit %+v, want RMax 12000 and K 6", m)
+	}
+	if rmse <= 0 || rmse > 0.03*12000 {
+		t.Errorf("RMSE %g msg/sec, want the noise", rmse)
+	}
+}
+
+// TestFitTau fits the time constant of a noisy trace of windows of 10
+// seconds stepping through prefetch counts.
+func TestFitTau(t *testing.T) {
+	truth := Model{RMax: 12000, K: 6, Tau: 7}
+	r := rand.New(rand.NewSource(1))
+	tr := &Trace{}
+	rate := truth.Static(2)
+	for i := 0; i < 40; i++ {
+		p := []float64{2, 10, 4, 30, 1}[i/8]
+		var mean float64
+		if i > 0 {
+			rate, mean = truth.Advance(rate, p, 10)
+		} else {
+			mean = rate
+		}
+		tr.Time = append(tr.Time, float64(10*i))
+		tr.Prefetch = append(tr.Prefetch, p)
+		tr.Rate = append(tr.Rate, mean*(1+0.01*r.NormFloat64()))
+	}
+
+	static := truth
+	static.Tau = 0
+	m, rmse, err := FitTau(static, tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(m.Tau-7) > 0.1*7 || m.RMax != truth.RMax || m.K != truth.K {
+		t.Errorf("fit %+v, want Tau 7 and the static curve kept", m)
+	}
+	if rmse > 0.02*12000 {
+		t.Errorf("RMSE %g msg/sec, want about the noise", rmse)
+	}
+}
+
+func TestFitErrors(t *testing.T) {
+	if _, _, err := FitStatic([]Measurement{{1, 100}}); err == nil {
+		t.Error("FitStatic accepted one measurement")
+	}
+	if _, _, err := FitStatic([]Measurement{{0, 100}, {1, 200}}); err == nil {
+		t.Error("FitStatic accepted a prefetch count of 0")
+	}
+	if _, _, err := FitStatic([]Measurement{{1, 0}, {2, 0}}); err == nil {
+		t.Error("FitStatic accepted measurements with no rate")
+	}
+	tr := &Trace{Time: []float64{0, 10, 10}, Prefetch: []float64{1, 2, 3}, Rate: []float64{1, 2, 3}}
+	if _, _, err := FitTau(FirstExperiment, tr); err == nil {
+		t.Error("FitTau accepted times that do not increase")
+	}
+	tr.Rate = tr.Rate[:2]
+	if _, _, err := FitTau(FirstExperiment, tr); err == nil {
+		t.Error("FitTau accepted columns of different lengths")
+	}
+}
@@ -0,0 +1,139 @@
+package plant
+
+import (
+	"math"
+	"time"
+
+	"rabbitMQ/controllers"
+	"rabbitMQ/fuzzy"
+	"rabbitMQ/prefetch"
+)
+
+// DefaultWindow is the control window of the consumers: they measure the
+// rate and adjust the prefetch count every 10 seconds.
+const DefaultWindow = 10 * time.Second
+
+// Response is a closed loop run, one entry per control window: the goal of
+// the window, the average rate measured over it, the prefetch count it ran
+// with and the controller output at its end.
+type Response struct {
+	Window   time.Duration
+	Goal     []float64
+	Rate     []float64
+	Prefetch []float64
+	Output   []float64
+}
+
+// Run closes the loop of controller c around m for one window per goal, the
+// way the consumers do while messages flow. The queue of the plant never
+// runs dry, so at the end of every window c gets the goal, the average rate
+// of the window, its error and change of error and the prefetch count, and
+// a applies its output. The plant starts in the steady state of the
+// current prefetch count of a. Zero window means DefaultWindow.
+func (m Model) Run(c *fuzzy.Controller, a *prefetch.Adjuster, goals []float64, window time.Duration) (*Response, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	if window == 0 {
+		window = DefaultWindow
+	}
+	r := &Response{Window: window}
+	dt := window.Seconds()
+	rate := m.Static(float64(a.Current()))
+	prevError, hasPrev := 0.0, false
+	for _, goal := range goals {
+		p := float64(a.Current())
+		var mean float64
+		rate, mean = m.Advance(rate, p, dt)
+
+		e := goal - mean
+		de := 0.0
+		if hasPrev {
+			de = e - prevError
+		}
+		prevError, hasPrev = e, true
+
+		u, err := c.Evaluate(map[string]float64{
+			controllers.Goal:       goal,
+			controllers.Error:      e,
+			controllers.DeltaError: de,
+			controllers.Rate:       mean,
+			controllers.Prefetch:   p,
+		})
+		if err != nil {
+			return nil, err
+		}
+		a.Apply(u)
+
+		r.Goal = append(r.Goal, goal)
+		r.Rate = append(r.Rate, mean)
+		r.Prefetch = append(r.Prefetch, p)
+		r.Output = append(r.Output, u)
+	}
+	return r, nil
+}
+
+// Step returns goals for a run of n windows stepping from one goal to
+// another at window at.
+func Step(from, to float64, at, n int) []float64 {
+	goals := make([]float64, n)
+	for i := range goals {
+		goals[i] = from
+		if i >= at {
+			goals[i] = to
+		}
+	}
+	return goals
+}
+
+// StepResponse measures the response to a step of the goal.
+type StepResponse struct {
+	// SettlingTime is the time from the step until the rate enters the
+	// band around the new goal for good. It is the rest of the run when
+	// the rate never settles, and Settled is false.
+	SettlingTime time.Duration
+	Settled      bool
+	// Overshoot is how far the rate went past the new goal, as a fraction
+	// of the step.
+	Overshoot float64
+	// SteadyStateError is the distance of the rate from the goal in the
+	// last window, as a fraction of the goal.
+	SteadyStateError float64
+}
+
+// Step measures the response of r to the step of the goal at window at. The
+// settling band is band times the size of the step on either side of the
+// new goal.
+func (r *Response) Step(at int, band float64) StepResponse {
+	n := len(r.Rate)
+	if at <= 0 || at >= n {
+		return StepResponse{}
+	}
+	from, to := r.Goal[at-1], r.Goal[at]
+	size := math.Abs(to - from)
+	dir := 1.0
+	if to < from {
+		dir = -1
+	}
+
+	var s StepResponse
+	settled := n
+	for k := n - 1; k >= at; k-- {
+		if math.Abs(r.Rate[k]-to) > band*size {
+			break
+		}
+		settled = k
+	}
+	s.Settled = settled < n
+	s.SettlingTime = time.Duration(settled-at+1) * r.Window
+	if !s.Settled {
+		s.SettlingTime = time.Duration(n-at) * r.Window
+	}
+	for k := at; k < n; k++ {
+		if size > 0 {
+			s.Overshoot = math.Max(s.Overshoot, dir*(r.Rate[k]-to)/size)
+		}
+	}
+	s.SteadyStateError = math.Abs(to-r.Rate[n-1]) / math.Abs(to)
+	return s
+}
@@ -0,0 +1,93 @@
+// Package plant models how the message rate of a consumer responds to its
+// prefetch count, so the prefetch controllers can be simulated and tuned
+// without a broker.
+package plant
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+)
+
+// Model is a first order plant: after a change of prefetch count p, the rate
+// moves towards the static rate
+//
+//	RMax · p / (p + K)
+//
+// exponentially, with time constant Tau. The rate saturates at RMax and
+// reaches half of it at a prefetch count of K.
+type Model struct {
+	RMax float64 `json:"rmax"` // msg/sec
+	K    float64 `json:"k"`    // prefetch messages
+	Tau  float64 `json:"tau"`  // seconds
+}
+
+// FirstExperiment is the static curve FitStatic fits to the Prefetch Count
+// X Rate measurements of the first experiment, in docs. The experiment held
+// each prefetch count fixed, so it says nothing of the dynamics: Tau is a
+// guess of half a control window.
+var FirstExperiment = Model{RMax: 16620.3, K: 3.985, Tau: 5}
+
+// Validate checks that the parameters of m are positive.
+func (m Model) Validate() error {
+	if !(m.RMax > 0) || !(m.K > 0) || !(m.Tau >= 0) || math.IsInf(m.RMax, 0) || math.IsInf(m.K, 0) || math.IsInf(m.Tau, 0) {
+		return fmt.Errorf("plant: invalid model %+v", m)
+	}
+	return nil
+}
+
+// Static returns the rate the plant settles at with prefetch count p.
+func (m Model) Static(p float64) float64 {
+	if p <= 0 {
+		return 0
+	}
+	return m.RMax * p / (p + m.K)
+}
+
+// Prefetch returns the prefetch count the plant settles at rate with, the
+// inverse of Static. It is +Inf for rates of RMax and above.
+func (m Model) Prefetch(rate float64) float64 {
+	if rate <= 0 {
+		return 0
+	}
+	if rate >= m.RMax {
+		return math.Inf(1)
+	}
+	return m.K * rate / (m.RMax - rate)
+}
+
+// Advance returns the rate dt seconds after rate, with prefetch count p
+// held, and the average rate over those dt seconds.
+func (m Model) Advance(rate, p, dt float64) (next, mean float64) {
+	target := m.Static(p)
+	if m.Tau == 0 || dt <= 0 {
+		return target, target
+	}
+	decay := math.Exp(-dt / m.Tau)
+	next = target + (rate-target)*decay
+	mean = target + (rate-target)*m.Tau/dt*(1-decay)
+	return next, mean
+}
+
+// Load reads a model saved by Save.
+func Load(path string) (Model, error) {
+	var m Model
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return m, fmt.Errorf("plant: %s: %w", path, err)
+	}
+	return m, m.Validate()
+}
+
+// Save writes m to path as JSON.
+func Save(path string, m Model) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
@@ -0,0 +1,237 @@
+// Package plot renders the simple charts used in the experiment reports, as
+// SVG or PNG, in the style of the spreadsheet charts in images/.
+package plot
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Colours of the spreadsheet chart palette.
+var (
+	Palette = []color.RGBA{
+		{0x42, 0x85, 0xf4, 0xff}, // blue
+		{0xea, 0x43, 0x35, 0xff}, // red
+		{0xfb, 0xbc, 0x04, 0xff}, // yellow
+		{0x34, 0xa8, 0x53, 0xff}, // green
+		{0xff, 0x6d, 0x01, 0xff}, // orange
+		{0x46, 0xbd, 0xc6, 0xff}, // teal
+		{0x7b, 0x1f, 0xa2, 0xff}, // purple
+	}
+
+	white     = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	black     = color.RGBA{0x00, 0x00, 0x00, 0xff}
+	titleGrey = color.RGBA{0x75, 0x75, 0x75, 0xff}
+	gridGrey  = color.RGBA{0xcc, 0xcc, 0xcc, 0xff}
+	axisGrey  = color.RGBA{0x33, 0x33, 0x33, 0xff}
+)
+
+type anchor int
+
+const (
+	start anchor = iota
+	middle
+	end
+)
+
+// canvas is the drawing surface the charts render on.
+type canvas interface {
+	line(x1, y1, x2, y2 float64, c color.RGBA, width float64, dashed bool)
+	polyline(xs, ys []float64, c color.RGBA, width float64, dashed bool)
+	rect(x, y, w, h float64, c color.RGBA)
+	// text draws s with its baseline at y. size is in pixels.
+	text(x, y float64, s string, size float64, a anchor, c color.RGBA, vertical bool)
+}
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// svgCanvas writes SVG elements.
+type svgCanvas struct {
+	b strings.Builder
+}
+
+func newSVG(width, height int) *svgCanvas {
+	s := &svgCanvas{}
+	fmt.Fprintf(&s.b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="Arial, Helvetica, sans-serif">`+"\n", width, height, width, height)
+	s.rect(0, 0, float64(width), float64(height), white)
+	return s
+}
+
+func (s *svgCanvas) line(x1, y1, x2, y2 float64, c color.RGBA, width float64, dashed bool) {
+	dash := ""
+	if dashed {
+		dash = ` stroke-dasharray="6,4"`
+	}
+	fmt.Fprintf(&s.b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="%g"%s/>`+"\n", x1, y1, x2, y2, hex(c), width, dash)
+}
+
+func (s *svgCanvas) polyline(xs, ys []float64, c color.RGBA, width float64, dashed bool) {
+	pts := make([]string, len(xs))
+	for i := range xs {
+		pts[i] = fmt.Sprintf("%.1f,%.1f", xs[i], ys[i])
+	}
+	dash := ""
+	if dashed {
+		dash = ` stroke-dasharray="6,4"`
+	}
+	fmt.Fprintf(&s.b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="%g" stroke-linejoin="round"%s/>`+"\n", strings.Join(pts, " "), hex(c), width, dash)
+}
+
+func (s *svgCanvas) rect(x, y, w, h float64, c color.RGBA) {
+	fmt.Fprintf(&s.b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"/>`+"\n", x, y, w, h, hex(c))
+}
+
+func (s *svgCanvas) text(x, y float64, str string, size float64, a anchor, c color.RGBA, vertical bool) {
+	anchors := []string{"start", "middle", "end"}
+	rotate := ""
+	if vertical {
+		rotate = fmt.Sprintf(` transform="rotate(-90 %.1f %.1f)"`, x, y)
+	}
+	fmt.Fprintf(&s.b, `<text x="%.1f" y="%.1f" font-size="%g" text-anchor="%s" fill="%s"%s>%s</text>`+"\n", x, y, size, anchors[a], hex(c), rotate, html.EscapeString(str))
+}
+
+func (s *svgCanvas) writeTo(w io.Writer) error {
+	_, err := io.WriteString(w, s.b.String()+"</svg>\n")
+	return err
+}
+
+// pngCanvas rasterizes onto an RGBA image. Text uses a fixed bitmap font,
+// scaled up by whole factors to approach the requested size.
+type pngCanvas struct {
+	img *image.RGBA
+}
+
+func newPNG(width, height int) *pngCanvas {
+	p := &pngCanvas{img: image.NewRGBA(image.Rect(0, 0, width, height))}
+	draw.Draw(p.img, p.img.Bounds(), &image.Uniform{white}, image.Point{}, draw.Src)
+	return p
+}
+
+func (p *pngCanvas) dot(x, y float64, c color.RGBA, width float64) {
+	r := math.Max(0.5, width/2)
+	for dy := -r; dy <= r; dy++ {
+		for dx := -r; dx <= r; dx++ {
+			p.img.SetRGBA(int(math.Round(x+dx)), int(math.Round(y+dy)), c)
+		}
+	}
+}
+
+func (p *pngCanvas) line(x1, y1, x2, y2 float64, c color.RGBA, width float64, dashed bool) {
+	n := int(math.Max(math.Abs(x2-x1), math.Abs(y2-y1))) + 1
+	for i := 0; i <= n; i++ {
+		if dashed && (i/5)%2 == 1 {
+			continue
+		}
+		t := float64(i) / float64(n)
+		p.dot(x1+t*(x2-x1), y1+t*(y2-y1), c, width)
+	}
+}
+
+func (p *pngCanvas) polyline(xs, ys []float64, c color.RGBA, width float64, dashed bool) {
+	if !dashed {
+		for i := 1; i < len(xs); i++ {
+			p.line(xs[i-1], ys[i-1], xs[i], ys[i], c, width, false)
+		}
+		return
+	}
+	// The dashes run on across segments, which may be shorter than a dash.
+	dist := 0.0
+	for i := 1; i < len(xs); i++ {
+		dx, dy := xs[i]-xs[i-1], ys[i]-ys[i-1]
+		n := int(math.Max(math.Abs(dx), math.Abs(dy))) + 1
+		step := math.Hypot(dx, dy) / float64(n)
+		for j := 0; j < n; j++ {
+			if int(dist/5)%2 == 0 {
+				t := float64(j) / float64(n)
+				p.dot(xs[i-1]+t*dx, ys[i-1]+t*dy, c, width)
+			}
+			dist += step
+		}
+	}
+}
+
+func (p *pngCanvas) rect(x, y, w, h float64, c color.RGBA) {
+	r := image.Rect(int(math.Round(x)), int(math.Round(y)), int(math.Round(x+w)), int(math.Round(y+h)))
+	draw.Draw(p.img, r, &image.Uniform{c}, image.Point{}, draw.Src)
+}
+
+func (p *pngCanvas) text(x, y float64, s string, size float64, a anchor, c color.RGBA, vertical bool) {
+	face := basicfont.Face7x13
+	scale := int(math.Max(1, math.Round(size/13)))
+	w := font.MeasureString(face, s).Ceil()
+	h := face.Height
+
+	// Render unscaled into a mask, then copy it scaled (and rotated).
+	mask := image.NewAlpha(image.Rect(0, 0, w, h))
+	d := &font.Drawer{Dst: mask, Src: image.Opaque, Face: face, Dot: fixed.P(0, face.Ascent)}
+	d.DrawString(s)
+
+	sw, sh := w*scale, h*scale
+	shift := []int{0, sw / 2, sw}[a]
+	for my := 0; my < h; my++ {
+		for mx := 0; mx < w; mx++ {
+			if mask.AlphaAt(mx, my).A < 0x80 {
+				continue
+			}
+			for k := 0; k < scale*scale; k++ {
+				tx := mx*scale + k%scale - shift
+				ty := my*scale + k/scale - face.Ascent*scale
+				px, py := int(x)+tx, int(y)+ty
+				if vertical {
+					px, py = int(x)+ty+sh/2, int(y)-tx
+				}
+				p.img.SetRGBA(px, py, c)
+			}
+		}
+	}
+}
+
+func (p *pngCanvas) writeTo(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if err := png.Encode(bw, p.img); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// niceTicks returns about n round tick values covering [min, max].
+func niceTicks(min, max float64, n int) []float64 {
+	if !(max > min) {
+		return []float64{min}
+	}
+	raw := (max - min) / float64(n)
+	mag := math.Pow(10, math.Floor(math.Log10(raw)))
+	step := mag
+	for _, m := range []float64{1, 2, 2.5, 5, 10} {
+		if m*mag >= raw {
+			step = m * mag
+			break
+		}
+	}
+	var ticks []float64
+	for t := math.Ceil(min/step-1e-9) * step; t <= max+step*1e-9; t += step {
+		ticks = append(ticks, math.Round(t/step)*step)
+	}
+	return ticks
+}
+
+func formatTick(v float64) string {
+	if v == 0 {
+		v = 0 // no "-0"
+	}
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.4f", v), "0"), ".")
+}
@@ -0,0 +1,253 @@
+package plot
+
+import (
+	"image/color"
+	"io"
+	"math"
+)
+
+// Default chart size, in pixels.
+const (
+	DefaultWidth  = 1000
+	DefaultHeight = 620
+)
+
+// Plot area margins.
+const (
+	marginLeft   = 100
+	marginRight  = 40
+	marginTop    = 90
+	marginBottom = 90
+)
+
+// Series is a named line of a Chart, drawn dashed when Dashed is set. A
+// series with no Name takes the colour of the series before it and is left
+// out of the legend, e.g. to draw a bound of that series.
+type Series struct {
+	Name   string
+	X, Y   []float64
+	Dashed bool
+}
+
+// colours returns the palette colour of every series of c.
+func (c *Chart) colours() []color.RGBA {
+	cols := make([]color.RGBA, len(c.Series))
+	k := -1
+	for i, s := range c.Series {
+		if s.Name != "" || k < 0 {
+			k++
+		}
+		cols[i] = Palette[k%len(Palette)]
+	}
+	return cols
+}
+
+// Label is a text centred above the point (X, Y) of a Chart.
+type Label struct {
+	X, Y float64
+	Text string
+}
+
+// Mark is a labelled dashed vertical line at X.
+type Mark struct {
+	X    float64
+	Text string
+}
+
+// Chart is a line chart of one or more series sharing the same axes.
+type Chart struct {
+	Title          string
+	XLabel, YLabel string
+	Series         []Series
+	// Labels are texts placed at data coordinates, e.g. term names.
+	Labels []Label
+	// Marks are dashed vertical lines, e.g. universe bounds.
+	Marks []Mark
+
+	// Width and Height default to DefaultWidth and DefaultHeight.
+	Width, Height int
+	// XMin, XMax, YMin and YMax fix the axis ranges; when both ends of an
+	// axis are zero the range is fitted to the data.
+	XMin, XMax float64
+	YMin, YMax float64
+}
+
+// SVG writes c as an SVG document.
+func (c *Chart) SVG(w io.Writer) error {
+	width, height := c.size()
+	s := newSVG(width, height)
+	c.draw(s, width, height)
+	return s.writeTo(w)
+}
+
+// PNG writes c as a PNG image.
+func (c *Chart) PNG(w io.Writer) error {
+	width, height := c.size()
+	p := newPNG(width, height)
+	c.draw(p, width, height)
+	return p.writeTo(w)
+}
+
+func (c *Chart) size() (int, int) {
+	w, h := c.Width, c.Height
+	if w <= 0 {
+		w = DefaultWidth
+	}
+	if h <= 0 {
+		h = DefaultHeight
+	}
+	return w, h
+}
+
+func (c *Chart) bounds() (xmin, xmax, ymin, ymax float64) {
+	xmin, xmax = c.XMin, c.XMax
+	ymin, ymax = c.YMin, c.YMax
+	fitX, fitY := xmin == 0 && xmax == 0, ymin == 0 && ymax == 0
+	if fitX {
+		xmin, xmax = math.Inf(1), math.Inf(-1)
+	}
+	if fitY {
+		ymin, ymax = math.Inf(1), math.Inf(-1)
+	}
+	for _, s := range c.Series {
+		for i := range s.X {
+			if fitX {
+				xmin, xmax = math.Min(xmin, s.X[i]), math.Max(xmax, s.X[i])
+			}
+			if fitY && !math.IsNaN(s.Y[i]) {
+				ymin, ymax = math.Min(ymin, s.Y[i]), math.Max(ymax, s.Y[i])
+			}
+		}
+	}
+	if math.IsInf(xmin, 0) || math.IsInf(xmax, 0) {
+		xmin, xmax = 0, 1
+	}
+	if math.IsInf(ymin, 0) || math.IsInf(ymax, 0) {
+		ymin, ymax = 0, 1
+	}
+	if fitY {
+		ticks := niceTicks(ymin, ymax, 6)
+		if len(ticks) > 1 {
+			step := ticks[1] - ticks[0]
+			ymin = math.Floor(ymin/step) * step
+			ymax = math.Max(ymax, math.Ceil(ymax/step)*step)
+		}
+	}
+	if xmin == xmax {
+		xmin, xmax = xmin-1, xmax+1
+	}
+	if ymin == ymax {
+		ymin, ymax = ymin-1, ymax+1
+	}
+	return xmin, xmax, ymin, ymax
+}
+
+// frame is the plot area of a chart and its mapping from data coordinates.
+type frame struct {
+	left, top, width, height float64
+	xmin, xmax, ymin, ymax   float64
+}
+
+func (f frame) x(v float64) float64 {
+	return f.left + (v-f.xmin)/(f.xmax-f.xmin)*f.width
+}
+
+func (f frame) y(v float64) float64 {
+	return f.top + f.height - (v-f.ymin)/(f.ymax-f.ymin)*f.height
+}
+
+// axes draws the title, the gridlines, the tick labels and the axis labels
+// of the spreadsheet chart style.
+func (f frame) axes(cv canvas, title, xlabel, ylabel string, totalHeight float64) {
+	cv.text(30, 45, title, 26, start, titleGrey, false)
+
+	for _, t := range niceTicks(f.ymin, f.ymax, 6) {
+		y := f.y(t)
+		cv.line(f.left, y, f.left+f.width, y, gridGrey, 1, false)
+		cv.text(f.left-10, y+5, formatTick(t), 13, end, axisGrey, false)
+	}
+	for _, t := range niceTicks(f.xmin, f.xmax, 10) {
+		x := f.x(t)
+		cv.line(x, f.top, x, f.top+f.height, gridGrey, 1, false)
+		cv.text(x, f.top+f.height+22, formatTick(t), 13, middle, axisGrey, false)
+	}
+	cv.line(f.left, f.top+f.height, f.left+f.width, f.top+f.height, axisGrey, 1, false)
+
+	cv.text(f.left+f.width/2, totalHeight-25, xlabel, 15, middle, axisGrey, false)
+	cv.text(30, f.top+f.height/2, ylabel, 15, middle, axisGrey, true)
+}
+
+func (c *Chart) draw(cv canvas, width, height int) {
+	xmin, xmax, ymin, ymax := c.bounds()
+	f := frame{
+		left: marginLeft, top: marginTop,
+		width:  float64(width - marginLeft - marginRight),
+		height: float64(height - marginTop - marginBottom),
+		xmin:   xmin, xmax: xmax, ymin: ymin, ymax: ymax,
+	}
+	f.axes(cv, c.Title, c.XLabel, c.YLabel, float64(height))
+
+	cols := c.colours()
+	for i, s := range c.Series {
+		col := cols[i]
+		var xs, ys []float64
+		flush := func() {
+			if len(xs) > 0 {
+				cv.polyline(xs, ys, col, 2, s.Dashed)
+			}
+			xs, ys = nil, nil
+		}
+		for j := range s.X {
+			if math.IsNaN(s.Y[j]) {
+				flush()
+				continue
+			}
+			y := math.Max(ymin, math.Min(ymax, s.Y[j]))
+			xs = append(xs, f.x(s.X[j]))
+			ys = append(ys, f.y(y))
+		}
+		flush()
+	}
+
+	for _, m := range c.Marks {
+		if m.X < xmin || m.X > xmax {
+			continue
+		}
+		x := f.x(m.X)
+		cv.line(x, f.top, x, f.top+f.height, axisGrey, 1, true)
+		cv.text(x, f.top-8, m.Text, 13, middle, axisGrey, false)
+	}
+	for _, l := range c.Labels {
+		if l.X < xmin || l.X > xmax || l.Y < ymin || l.Y > ymax {
+			continue
+		}
+		cv.text(f.x(l.X), f.y(l.Y)-8, l.Text, 13, middle, axisGrey, false)
+	}
+
+	named := 0
+	for _, s := range c.Series {
+		if s.Name != "" {
+			named++
+		}
+	}
+	if named > 1 {
+		c.legend(cv, float64(width))
+	}
+}
+
+// legend lists the series in a row above the plot area, right aligned.
+func (c *Chart) legend(cv canvas, width float64) {
+	x := width - marginRight
+	cols := c.colours()
+	for i := len(c.Series) - 1; i >= 0; i-- {
+		s := c.Series[i]
+		if s.Name == "" {
+			continue
+		}
+		w := 8*float64(len(s.Name)) + 30
+		x -= w
+		cv.line(x, 65, x+18, 65, cols[i], 3, s.Dashed)
+		cv.text(x+24, 70, s.Name, 13, start, axisGrey, false)
+		x -= 10
+	}
+}
@@ -0,0 +1,139 @@
+package plot
+
+import (
+	"image/color"
+	"io"
+	"math"
+)
+
+// colorbarWidth is the room kept right of a heatmap for its colour scale.
+const colorbarWidth = 90
+
+// Heatmap shades a grid of values Z[j][i] at (X[i], Y[j]), on a diverging
+// scale from red (lowest) through white to blue (highest).
+type Heatmap struct {
+	Title          string
+	XLabel, YLabel string
+	X, Y           []float64
+	Z              [][]float64
+
+	// Width and Height default to DefaultWidth and DefaultHeight.
+	Width, Height int
+}
+
+// SVG writes h as an SVG document.
+func (h *Heatmap) SVG(w io.Writer) error {
+	width, height := h.size()
+	s := newSVG(width, height)
+	h.draw(s, width, height)
+	return s.writeTo(w)
+}
+
+// PNG writes h as a PNG image.
+func (h *Heatmap) PNG(w io.Writer) error {
+	width, height := h.size()
+	p := newPNG(width, height)
+	h.draw(p, width, height)
+	return p.writeTo(w)
+}
+
+func (h *Heatmap) size() (int, int) {
+	w, ht := h.Width, h.Height
+	if w <= 0 {
+		w = DefaultWidth
+	}
+	if ht <= 0 {
+		ht = DefaultHeight
+	}
+	return w, ht
+}
+
+// zrange returns the range of the shading, symmetric around zero when the
+// values change sign so that white means zero.
+func (h *Heatmap) zrange() (float64, float64) {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, row := range h.Z {
+		for _, z := range row {
+			if !math.IsNaN(z) {
+				lo, hi = math.Min(lo, z), math.Max(hi, z)
+			}
+		}
+	}
+	if math.IsInf(lo, 0) {
+		return 0, 1
+	}
+	if lo < 0 && hi > 0 {
+		m := math.Max(-lo, hi)
+		lo, hi = -m, m
+	}
+	if lo == hi {
+		lo, hi = lo-1, hi+1
+	}
+	return lo, hi
+}
+
+func mix(a, b color.RGBA, t float64) color.RGBA {
+	f := func(x, y uint8) uint8 { return uint8(math.Round(float64(x) + t*(float64(y)-float64(x)))) }
+	return color.RGBA{f(a.R, b.R), f(a.G, b.G), f(a.B, b.B), 0xff}
+}
+
+// shade maps t in [0, 1] onto the diverging scale.
+func shade(t float64) color.RGBA {
+	t = math.Max(0, math.Min(1, t))
+	if t < 0.5 {
+		return mix(Palette[1], white, t*2)
+	}
+	return mix(white, Palette[0], t*2-1)
+}
+
+// edges returns the cell boundaries around the centres cs.
+func edges(cs []float64) []float64 {
+	e := make([]float64, len(cs)+1)
+	if len(cs) == 1 {
+		e[0], e[1] = cs[0]-0.5, cs[0]+0.5
+		return e
+	}
+	for i := 1; i < len(cs); i++ {
+		e[i] = (cs[i-1] + cs[i]) / 2
+	}
+	e[0] = cs[0] - (e[1] - cs[0])
+	e[len(cs)] = cs[len(cs)-1] + (cs[len(cs)-1] - e[len(cs)-1])
+	return e
+}
+
+func (h *Heatmap) draw(cv canvas, width, height int) {
+	if len(h.X) == 0 || len(h.Y) == 0 {
+		return
+	}
+	ex, ey := edges(h.X), edges(h.Y)
+	f := frame{
+		left: marginLeft, top: marginTop,
+		width:  float64(width - marginLeft - marginRight - colorbarWidth),
+		height: float64(height - marginTop - marginBottom),
+		xmin:   ex[0], xmax: ex[len(ex)-1], ymin: ey[0], ymax: ey[len(ey)-1],
+	}
+	lo, hi := h.zrange()
+
+	for j := range h.Y {
+		for i := range h.X {
+			z := h.Z[j][i]
+			if math.IsNaN(z) {
+				continue
+			}
+			x0, x1 := f.x(ex[i]), f.x(ex[i+1])
+			y0, y1 := f.y(ey[j+1]), f.y(ey[j])
+			cv.rect(x0, y0, x1-x0+0.5, y1-y0+0.5, shade((z-lo)/(hi-lo)))
+		}
+	}
+	f.axes(cv, h.Title, h.XLabel, h.YLabel, float64(height))
+
+	// Colour scale.
+	bx := f.left + f.width + 30
+	const steps = 50
+	for k := 0; k < steps; k++ {
+		y := f.top + f.height*float64(k)/steps
+		cv.rect(bx, y, 20, f.height/steps+0.5, shade(1-float64(k)/steps))
+	}
+	cv.text(bx+24, f.top+10, formatTick(hi), 13, start, axisGrey, false)
+	cv.text(bx+24, f.top+f.height, formatTick(lo), 13, start, axisGrey, false)
+}
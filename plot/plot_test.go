@@ -0,0 +1,71 @@
+package plot
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// golden compares got with the file name in testdata, or rewrites the file
+// with -update.
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	file := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(file, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from the golden file, run go test ./plot -update and review the diff", name)
+	}
+}
+
+func TestChartSVG(t *testing.T) {
+	c := &Chart{
+		Title:  "Terms",
+		XLabel: "error",
+		YLabel: "membership",
+		Series: []Series{
+			{Name: "LOW", X: []float64{-10, 0, 10}, Y: []float64{1, 1, 0}},
+			{Name: "HIGH", X: []float64{0, 10, 20}, Y: []float64{0, 1, 1}},
+			{X: []float64{0, 10, 20}, Y: []float64{0, 0.5, 0.5}, Dashed: true},
+		},
+		Labels: []Label{{X: -5, Y: 1, Text: "LOW"}, {X: 15, Y: 1, Text: "HIGH"}},
+		Marks:  []Mark{{X: -10, Text: "min"}, {X: 20, Text: "max"}},
+		Width:  400,
+		Height: 300,
+	}
+	var buf bytes.Buffer
+	if err := c.SVG(&buf); err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "chart.svg", buf.Bytes())
+}
+
+func TestHeatmapSVG(t *testing.T) {
+	h := &Heatmap{
+		Title:  "Surface",
+		XLabel: "x",
+		YLabel: "y",
+		X:      []float64{0, 1, 2},
+		Y:      []float64{0, 1},
+		Z:      [][]float64{{-1, 0, 1}, {-2, 0.5, 2}},
+		Width:  400,
+		Height: 300,
+	}
+	var buf bytes.Buffer
+	if err := h.SVG(&buf); err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "heatmap.svg", buf.Bytes())
+}
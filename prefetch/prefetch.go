@@ -0,0 +1,57 @@
+package prefetch
+
+import (
+	"fmt"
+	"math"
+)
+
+// Adjuster turns the crisp output of a fuzzy controller into a new prefetch
+// count for the consumer channel.
+type Adjuster struct {
+	Min  int     // lowest prefetch count that may be applied
+	Max  int     // highest prefetch count that may be applied
+	Step float64 // prefetch messages per unit of controller output
+
+	current int
+}
+
+// NewAdjuster returns an Adjuster starting at initial, clamped to [min, max].
+// The bounds must satisfy 1 <= min <= max: a prefetch count of 0 means no
+// limit to the broker.
+func NewAdjuster(initial, min, max int, step float64) (*Adjuster, error) {
+	if min < 1 || min > max {
+		return nil, fmt.Errorf("prefetch: invalid bounds [%d, %d], need 1 <= min <= max", min, max)
+	}
+	a := &Adjuster{Min: min, Max: max, Step: step}
+	a.current = a.clamp(initial)
+	return a, nil
+}
+
+func (a *Adjuster) clamp(n int) int {
+	if n < a.Min {
+		return a.Min
+	}
+	if n > a.Max {
+		return a.Max
+	}
+	return n
+}
+
+// Current returns the prefetch count that was last applied.
+func (a *Adjuster) Current() int {
+	return a.current
+}
+
+// Apply scales the controller output u by Step, adds it to the current
+// prefetch count and keeps the result within [Min, Max]. It reports whether
+// the count changed, so callers only re-issue Qos when needed.
+func (a *Adjuster) Apply(u float64) (int, bool) {
+	if math.IsNaN(u) || math.IsInf(u, 0) {
+		return a.current, false
+	}
+
+	next := a.clamp(a.current + int(math.Round(u*a.Step)))
+	changed := next != a.current
+	a.current = next
+	return next, changed
+}
@@ -0,0 +1,237 @@
+// Package sim is a discrete-event simulation of a RabbitMQ queue and one
+// consumer, detailed enough to show the effect of the prefetch count on
+// throughput: the broker keeps at most prefetch unacknowledged messages in
+// flight, every delivery and acknowledgement crosses the network, and the
+// consumer processes one message at a time.
+package sim
+
+import (
+	"container/heap"
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+
+	"rabbitMQ/controllers"
+	"rabbitMQ/fuzzy"
+	"rabbitMQ/plant"
+	"rabbitMQ/prefetch"
+)
+
+// Config describes the simulated system.
+type Config struct {
+	// Backlog is the number of messages in the queue at the start. A
+	// negative backlog never runs dry.
+	Backlog int
+	// ArrivalRate is the mean rate of the Poisson process publishing to the
+	// queue during the run, in msg/sec. Zero publishes nothing.
+	ArrivalRate float64
+	// ServiceTime is the mean time the consumer takes to process one
+	// message, exponentially distributed.
+	ServiceTime time.Duration
+	// RTT is the network round trip between the broker and the consumer: a
+	// delivery and an acknowledgement each take half of it.
+	RTT time.Duration
+	// Noise is the standard deviation of gaussian noise added to the rate
+	// measured every window, relative to the rate, standing for the coarse
+	// rate measurement of the consumers.
+	Noise float64
+	// Window is the control window. Zero means plant.DefaultWindow.
+	Window time.Duration
+	// Seed seeds the random arrivals, service times and noise.
+	Seed int64
+}
+
+// Window is the state of the simulation over one control window.
+type Window struct {
+	End time.Duration
+	// Goal is the goal of the window, Rate the measured rate and Prefetch
+	// the prefetch count the window ran with. Output is the controller
+	// output at the end of the window.
+	Goal, Rate, Prefetch, Output float64
+	// Queue is the number of messages waiting at the broker at the end of
+	// the window, -1 for an endless backlog.
+	Queue int
+	// Latency is the mean time from delivery by the broker to the end of
+	// processing of the messages processed in the window: the time they
+	// spent in flight and in the prefetch buffer of the consumer.
+	Latency time.Duration
+}
+
+type eventKind int
+
+const (
+	arrive  eventKind = iota // a message is published
+	deliver                  // a message reaches the consumer
+	done                     // the consumer has processed a message
+	ack                      // an acknowledgement reaches the broker
+	tick                     // a control window ends
+)
+
+type event struct {
+	at   time.Duration
+	seq  int // breaks ties in scheduling order
+	kind eventKind
+	sent time.Duration // delivery time of the message, for latency
+}
+
+type eventQueue []event
+
+func (q eventQueue) Len() int { return len(q) }
+func (q eventQueue) Less(i, j int) bool {
+	if q[i].at != q[j].at {
+		return q[i].at < q[j].at
+	}
+	return q[i].seq < q[j].seq
+}
+func (q eventQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *eventQueue) Push(x any)   { *q = append(*q, x.(event)) }
+func (q *eventQueue) Pop() any {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return e
+}
+
+type simulation struct {
+	cfg    Config
+	rand   *rand.Rand
+	events eventQueue
+	seq    int
+	now    time.Duration
+
+	queue    int             // messages waiting at the broker
+	unacked  int             // messages delivered and not yet acknowledged
+	buffer   []time.Duration // delivery times of the messages waiting at the consumer
+	busy     bool            // the consumer is processing a message
+	prefetch int
+
+	processed int           // messages processed in the current window
+	latency   time.Duration // their total latency
+}
+
+func (s *simulation) schedule(at time.Duration, kind eventKind, sent time.Duration) {
+	s.seq++
+	heap.Push(&s.events, event{at: at, seq: s.seq, kind: kind, sent: sent})
+}
+
+func (s *simulation) exp(mean time.Duration) time.Duration {
+	return time.Duration(s.rand.ExpFloat64() * float64(mean))
+}
+
+// dispatch delivers queued messages while the prefetch count allows.
+func (s *simulation) dispatch() {
+	for s.unacked < s.prefetch && s.queue != 0 {
+		if s.queue > 0 {
+			s.queue--
+		}
+		s.unacked++
+		s.schedule(s.now+s.cfg.RTT/2, deliver, s.now)
+	}
+}
+
+// serve starts processing the next buffered message if the consumer is idle.
+func (s *simulation) serve() {
+	if s.busy || len(s.buffer) == 0 {
+		return
+	}
+	sent := s.buffer[0]
+	s.buffer = s.buffer[1:]
+	s.busy = true
+	s.schedule(s.now+s.exp(s.cfg.ServiceTime), done, sent)
+}
+
+// Run simulates one control window per goal in goals. At the end of every
+// window in which messages were processed c gets the goal, the measured
+// rate of the window, its error and change of error and the prefetch count,
+// like in the consumers, and a applies its output; the broker applies a new
+// prefetch count to the deliveries that follow. As in the consumers, a
+// window without messages leaves the prefetch count alone. A nil c keeps
+// the prefetch count of a.
+func Run(cfg Config, c *fuzzy.Controller, a *prefetch.Adjuster, goals []float64) ([]Window, error) {
+	if !(cfg.ServiceTime > 0) || cfg.RTT < 0 || cfg.ArrivalRate < 0 || cfg.Noise < 0 {
+		return nil, errors.New("sim: service time must be positive and the RTT, arrival rate and noise not negative")
+	}
+	if cfg.Window == 0 {
+		cfg.Window = plant.DefaultWindow
+	}
+	s := &simulation{
+		cfg:      cfg,
+		rand:     rand.New(rand.NewSource(cfg.Seed)),
+		queue:    cfg.Backlog,
+		prefetch: a.Current(),
+	}
+	if s.queue < 0 {
+		s.queue = -1
+	}
+	if cfg.ArrivalRate > 0 {
+		s.schedule(s.exp(time.Duration(float64(time.Second)/cfg.ArrivalRate)), arrive, 0)
+	}
+	s.schedule(cfg.Window, tick, 0)
+	s.dispatch()
+
+	var windows []Window
+	prevError, hasPrev := 0.0, false
+	for len(windows) < len(goals) {
+		ev := heap.Pop(&s.events).(event)
+		s.now = ev.at
+		switch ev.kind {
+		case arrive:
+			if s.queue >= 0 {
+				s.queue++
+			}
+			s.schedule(s.now+s.exp(time.Duration(float64(time.Second)/cfg.ArrivalRate)), arrive, 0)
+			s.dispatch()
+		case deliver:
+			s.buffer = append(s.buffer, ev.sent)
+			s.serve()
+		case done:
+			s.busy = false
+			s.processed++
+			s.latency += s.now - ev.sent
+			s.schedule(s.now+cfg.RTT/2, ack, 0)
+			s.serve()
+		case ack:
+			s.unacked--
+			s.dispatch()
+		case tick:
+			goal := goals[len(windows)]
+			w := Window{End: s.now, Goal: goal, Prefetch: float64(s.prefetch), Queue: s.queue}
+			w.Rate = float64(s.processed) / cfg.Window.Seconds()
+			if cfg.Noise > 0 {
+				w.Rate = math.Max(0, w.Rate*(1+cfg.Noise*s.rand.NormFloat64()))
+			}
+			processed := s.processed
+			if processed > 0 {
+				w.Latency = s.latency / time.Duration(processed)
+			}
+			s.processed, s.latency = 0, 0
+
+			if c != nil && processed > 0 {
+				e := goal - w.Rate
+				de := 0.0
+				if hasPrev {
+					de = e - prevError
+				}
+				prevError, hasPrev = e, true
+
+				u, err := c.Evaluate(map[string]float64{
+					controllers.Goal:       goal,
+					controllers.Error:      e,
+					controllers.DeltaError: de,
+					controllers.Rate:       w.Rate,
+					controllers.Prefetch:   w.Prefetch,
+				})
+				if err != nil {
+					return windows, err
+				}
+				w.Output = u
+				s.prefetch, _ = a.Apply(u)
+				s.dispatch()
+			}
+			windows = append(windows, w)
+			s.schedule(s.now+cfg.Window, tick, 0)
+		}
+	}
+	return windows, nil
+}
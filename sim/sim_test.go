@@ -0,0 +1,132 @@
+package sim
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"rabbitMQ/plant"
+	"rabbitMQ/prefetch"
+)
+
+// run simulates n windows of cfg at a fixed prefetch count.
+func run(t *testing.T, cfg Config, prefetchCount, n int) []Window {
+	t.Helper()
+	a, err := prefetch.NewAdjuster(prefetchCount, 1, 1000, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	windows, err := Run(cfg, nil, a, make([]float64, n))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(windows) != n {
+		t.Fatalf("%d windows, want %d", len(windows), n)
+	}
+	return windows
+}
+
+// mean returns the mean rate and latency of windows.
+func mean(windows []Window) (rate float64, latency time.Duration) {
+	for _, w := range windows {
+		rate += w.Rate
+		latency += w.Latency
+	}
+	return rate / float64(len(windows)), latency / time.Duration(len(windows))
+}
+
+// TestRunThroughput checks the two bounds on throughput with an endless
+// backlog: prefetch messages per round trip when the network dominates,
+// one message per service time when processing does. The mean latency,
+// from delivery by the broker to the end of processing, is then a round
+// trip half and a service time in the first case, and by Little's law the
+// prefetch count times the service time, less the half round trip of the
+// acknowledgement, in the second.
+func TestRunThroughput(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		cfg      Config
+		prefetch int
+		rate     float64
+		latency  time.Duration
+		bound    string
+	}{
+		{
+			name:     "network bound",
+			cfg:      Config{Backlog: -1, ServiceTime: time.Millisecond, RTT: 100 * time.Millisecond, Seed: 1},
+			prefetch: 5,
+			// 5 messages per round trip and service time.
+			rate:    5 / 0.101,
+			latency: 51 * time.Millisecond,
+			bound:   "prefetch/RTT",
+		},
+		{
+			name:     "service bound",
+			cfg:      Config{Backlog: -1, ServiceTime: 10 * time.Millisecond, RTT: time.Millisecond, Seed: 1},
+			prefetch: 50,
+			rate:     100,
+			latency:  50*10*time.Millisecond - time.Millisecond/2,
+			bound:    "1/service time",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rate, latency := mean(run(t, tc.cfg, tc.prefetch, 6))
+			if math.Abs(rate-tc.rate) > 0.05*tc.rate {
+				t.Errorf("rate %.2f msg/sec, want %.2f, the %s bound", rate, tc.rate, tc.bound)
+			}
+			if d := math.Abs(float64(latency - tc.latency)); d > 0.05*float64(tc.latency) {
+				t.Errorf("mean latency %v, want %v", latency, tc.latency)
+			}
+		})
+	}
+
+	// Past the bandwidth-delay product more prefetch buys nothing.
+	cfg := Config{Backlog: -1, ServiceTime: 10 * time.Millisecond, RTT: 100 * time.Millisecond, Seed: 2}
+	small, _ := mean(run(t, cfg, 2, 6))
+	large, _ := mean(run(t, cfg, 200, 6))
+	if limit := 2 / 0.11; math.Abs(small-limit) > 0.1*limit {
+		t.Errorf("rate with prefetch 2 = %.2f msg/sec, want about %.2f", small, limit)
+	}
+	if math.Abs(large-100) > 5 {
+		t.Errorf("rate with prefetch 200 = %.2f msg/sec, want about 100", large)
+	}
+}
+
+// TestRunBacklogDrains checks that a finite backlog is processed exactly once
+// and that the windows after it has drained report no rate and no latency.
+func TestRunBacklogDrains(t *testing.T) {
+	cfg := Config{Backlog: 500, ServiceTime: 10 * time.Millisecond, RTT: time.Millisecond, Seed: 3}
+	windows := run(t, cfg, 10, 3)
+	total := 0.0
+	for _, w := range windows {
+		total += w.Rate * plant.DefaultWindow.Seconds()
+	}
+	if math.Abs(total-500) > 1e-6 {
+		t.Errorf("%g messages processed, want the backlog of 500", total)
+	}
+	if windows[0].Queue != 0 {
+		t.Errorf("%d messages left after the first window, want 0", windows[0].Queue)
+	}
+	for _, w := range windows[1:] {
+		if w.Rate != 0 || w.Latency != 0 {
+			t.Errorf("window ending at %v: rate %g and latency %v after the backlog drained", w.End, w.Rate, w.Latency)
+		}
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	a, err := prefetch.NewAdjuster(1, 1, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cfg := range []Config{
+		{},
+		{ServiceTime: time.Millisecond, RTT: -time.Millisecond},
+		{ServiceTime: time.Millisecond, ArrivalRate: -1},
+		{ServiceTime: time.Millisecond, Noise: -0.1},
+	} {
+		if _, err := Run(cfg, nil, a, []float64{0}); err == nil {
+			t.Errorf("Run accepted %+v", cfg)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"rabbitMQ/controllers"
+	"rabbitMQ/internal/cli"
+	"rabbitMQ/plant"
+	"rabbitMQ/plot"
+	"rabbitMQ/prefetch"
+	"rabbitMQ/sim"
+)
+
+func failOnError(err error, msg string) {
+	if err != nil {
+		log.Fatalf("%s: %s", msg, err)
+	}
+}
+
+// simulate runs the controller of a consumer against a simulated queue
+// instead of a broker: by default a discrete-event simulation of the queue,
+// the network and the consumer, or with -plant the first order plant model
+// fitted to the measurements. The goal may step to -step-goal after
+// -step-at windows. It writes one CSV row per control window and charts of
+// the rate and the prefetch count over time.
+func main() {
+	variantName := flag.String("variant", "gaussian", "built-in controller to run: gaussian, triangular or bell")
+	goal := flag.Float64("goal", 0, "rate to reach, in msg/sec (default the variant's goal)")
+	stepGoal := flag.Float64("step-goal", 0, "goal after -step-at windows, in msg/sec (0 = no step)")
+	stepAt := flag.Int("step-at", 30, "window the goal steps to -step-goal at")
+	windows := flag.Int("windows", 60, "control windows to simulate")
+	window := flag.Duration("window", plant.DefaultWindow, "control window")
+	initialPrefetch := flag.Int("prefetch", 62, "initial prefetch count")
+	minPrefetch := flag.Int("prefetch-min", 1, "lowest prefetch count the controller may set")
+	maxPrefetch := flag.Int("prefetch-max", 100, "highest prefetch count the controller may set")
+	step := flag.Float64("prefetch-step", 2, "prefetch messages per unit of controller output")
+	backlog := flag.Int("backlog", -1, "messages in the queue at the start (-1 = the queue never runs dry)")
+	arrivalRate := flag.Float64("arrival-rate", 0, "mean rate of Poisson publishing during the run, in msg/sec")
+	service := flag.Duration("service", 60*time.Microsecond, "mean time the consumer takes per message")
+	rtt := flag.Duration("rtt", 240*time.Microsecond, "network round trip between broker and consumer")
+	noise := flag.Float64("noise", 0, "relative standard deviation of the noise of the measured rate")
+	seed := flag.Int64("seed", 1, "random seed")
+	plantPath := flag.String("plant", "", "run the plant model in this JSON file instead, or \"first-experiment\" for the built-in fit")
+	out := flag.String("o", "sim", "output path prefix of the .csv, .svg and .png files")
+	controllerFlags := controllers.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	variant, ok := controllers.Lookup(*variantName)
+	if !ok {
+		log.Fatalf("Unknown variant %q", *variantName)
+	}
+	if *goal == 0 {
+		*goal = variant.Goal
+	}
+	c, err := controllerFlags.Controller(variant)
+	failOnError(err, "Failed to build the fuzzy controller")
+	a, err := prefetch.NewAdjuster(*initialPrefetch, *minPrefetch, *maxPrefetch, *step)
+	failOnError(err, "Invalid prefetch bounds")
+
+	goals := make([]float64, *windows)
+	for i := range goals {
+		goals[i] = *goal
+		if *stepGoal != 0 && i >= *stepAt {
+			goals[i] = *stepGoal
+		}
+	}
+
+	var ws []sim.Window
+	if *plantPath != "" {
+		model := plant.FirstExperiment
+		if *plantPath != "first-experiment" {
+			model, err = plant.Load(*plantPath)
+			failOnError(err, "Failed to load the plant model")
+		}
+		r, err := model.Run(c, a, goals, *window)
+		failOnError(err, "Failed to run the plant model")
+		for i := range r.Rate {
+			ws = append(ws, sim.Window{
+				End:  time.Duration(i+1) * *window,
+				Goal: r.Goal[i], Rate: r.Rate[i], Prefetch: r.Prefetch[i], Output: r.Output[i],
+				Queue: -1,
+			})
+		}
+	} else {
+		cfg := sim.Config{
+			Backlog: *backlog, ArrivalRate: *arrivalRate, ServiceTime: *service, RTT: *rtt,
+			Noise: *noise, Window: *window, Seed: *seed,
+		}
+		ws, err = sim.Run(cfg, c, a, goals)
+		failOnError(err, "Failed to simulate")
+	}
+
+	var ts, goalY, rateY, prefetchY []float64
+	for _, w := range ws {
+		log.Printf("%v: rate %.0f msg/sec, goal %.0f, prefetch %.0f, output %.2f", w.End, w.Rate, w.Goal, w.Prefetch, w.Output)
+		ts = append(ts, w.End.Seconds())
+		goalY = append(goalY, w.Goal)
+		rateY = append(rateY, w.Rate)
+		prefetchY = append(prefetchY, w.Prefetch)
+	}
+
+	failOnError(cli.WriteFile(*out+".csv", func(f io.Writer) error {
+		w := csv.NewWriter(f)
+		w.Write([]string{"time", "goal", "rate", "prefetch", "output", "queue", "latency_ms"})
+		for _, win := range ws {
+			queue := ""
+			if win.Queue >= 0 {
+				queue = strconv.Itoa(win.Queue)
+			}
+			w.Write([]string{
+				cli.FormatFloat(win.End.Seconds()), cli.FormatFloat(win.Goal), cli.FormatFloat(win.Rate),
+				cli.FormatFloat(win.Prefetch), cli.FormatFloat(win.Output), queue,
+				cli.FormatFloat(float64(win.Latency) / float64(time.Millisecond)),
+			})
+		}
+		w.Flush()
+		return w.Error()
+	}), "Failed to write")
+
+	rate := &plot.Chart{
+		Title:  fmt.Sprintf("Simulated rate - %s", variant.Name),
+		XLabel: "Time (s)",
+		YLabel: "Rate (msg/sec)",
+		Series: []plot.Series{{Name: "rate", X: ts, Y: rateY}, {Name: "goal", X: ts, Y: goalY, Dashed: true}},
+		YMin:   0,
+		YMax:   math.Max(cli.Max(rateY), cli.Max(goalY)) * 1.1,
+	}
+	failOnError(cli.WriteFile(*out+"-rate.svg", func(f io.Writer) error { return rate.SVG(f) }), "Failed to write")
+	failOnError(cli.WriteFile(*out+"-rate.png", func(f io.Writer) error { return rate.PNG(f) }), "Failed to write")
+
+	pc := &plot.Chart{
+		Title:  fmt.Sprintf("Simulated prefetch count - %s", variant.Name),
+		XLabel: "Time (s)",
+		YLabel: "Prefetch count",
+		Series: []plot.Series{{Name: "prefetch", X: ts, Y: prefetchY}},
+	}
+	failOnError(cli.WriteFile(*out+"-prefetch.svg", func(f io.Writer) error { return pc.SVG(f) }), "Failed to write")
+	failOnError(cli.WriteFile(*out+"-prefetch.png", func(f io.Writer) error { return pc.PNG(f) }), "Failed to write")
+}
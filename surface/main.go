@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+
+	"rabbitMQ/controllers"
+	"rabbitMQ/fuzzy"
+	"rabbitMQ/internal/cli"
+	"rabbitMQ/plot"
+)
+
+func failOnError(err error, msg string) {
+	if err != nil {
+		log.Fatalf("%s: %s", msg, err)
+	}
+}
+
+// grid returns n evenly spaced points of the universe of v.
+func grid(v *fuzzy.Variable, n int) []float64 {
+	xs := make([]float64, n)
+	for i := range xs {
+		xs[i] = v.Min + float64(i)*(v.Max-v.Min)/float64(n-1)
+	}
+	return xs
+}
+
+// evaluate returns the crisp output of c at in, or NaN when c fails there.
+func evaluate(c *fuzzy.Controller, in map[string]float64) float64 {
+	u, err := c.Evaluate(in)
+	if err != nil {
+		return math.NaN()
+	}
+	return u
+}
+
+// surface sweeps the inputs of the consumers' controllers and writes the
+// control surface of each, side by side: a curve of output against error,
+// or with two inputs (-pd) a grid over error and change of error. The CSV
+// has a column per input followed by a column per controller; the first
+// two inputs span the grid, rate and prefetch are held at -rate and
+// -prefetch. With -config it sweeps the controller in that file instead,
+// labelled with the file name.
+func main() {
+	variantName := flag.String("variant", "", "built-in controller to sweep: gaussian, triangular or bell (default all; with -config, the file is the only controller)")
+	samples := flag.Int("samples", 401, "points each swept input universe is split into")
+	rate := flag.Float64("rate", 0, "message rate held while sweeping, for -tsk")
+	prefetch := flag.Float64("prefetch", 0, "prefetch count held while sweeping, for -tsk")
+	out := flag.String("o", "surface", "output path prefix of the .csv, .svg and .png files")
+	controllerFlags := controllers.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	variants, err := controllerFlags.Variants(*variantName)
+	failOnError(err, "Invalid -variant")
+	if *samples < 2 {
+		log.Fatalf("-samples must be at least 2")
+	}
+
+	var cs []*fuzzy.Controller
+	for _, v := range variants {
+		c, err := controllerFlags.Controller(v)
+		failOnError(err, "Failed to build the fuzzy controller")
+		// The sweep runs over the universes themselves, already scaled.
+		c.Scales = nil
+		cs = append(cs, c)
+	}
+
+	// The grid is taken from the first controller; the others are
+	// evaluated on the same points.
+	inputs := cs[0].Inputs
+	if len(inputs) > 2 {
+		inputs = inputs[:2]
+	}
+	xs := grid(inputs[0], *samples)
+	ys := []float64{0}
+	if len(inputs) == 2 {
+		ys = grid(inputs[1], *samples)
+	}
+
+	// z[k][j][i] is the output of controller k at (xs[i], ys[j]).
+	z := make([][][]float64, len(cs))
+	for k, c := range cs {
+		z[k] = make([][]float64, len(ys))
+		for j, y := range ys {
+			z[k][j] = make([]float64, len(xs))
+			for i, x := range xs {
+				in := map[string]float64{
+					inputs[0].Name:       x,
+					controllers.Rate:     *rate,
+					controllers.Prefetch: *prefetch,
+				}
+				if len(inputs) == 2 {
+					in[inputs[1].Name] = y
+				}
+				z[k][j][i] = evaluate(c, in)
+			}
+		}
+	}
+
+	failOnError(cli.WriteFile(*out+".csv", func(f io.Writer) error {
+		w := csv.NewWriter(f)
+		header := []string{inputs[0].Name}
+		if len(inputs) == 2 {
+			header = append(header, inputs[1].Name)
+		}
+		for _, v := range variants {
+			header = append(header, v.Name)
+		}
+		w.Write(header)
+		for j, y := range ys {
+			for i, x := range xs {
+				row := []string{cli.FormatFloat(x)}
+				if len(inputs) == 2 {
+					row = append(row, cli.FormatFloat(y))
+				}
+				for k := range cs {
+					row = append(row, cli.FormatFloat(z[k][j][i]))
+				}
+				w.Write(row)
+			}
+		}
+		w.Flush()
+		return w.Error()
+	}), "Failed to write")
+
+	if len(inputs) == 1 {
+		chart := &plot.Chart{
+			Title:  "Control surface",
+			XLabel: inputs[0].Name,
+			YLabel: cs[0].Output.Name,
+		}
+		for k, v := range variants {
+			chart.Series = append(chart.Series, plot.Series{Name: v.Name, X: xs, Y: z[k][0]})
+		}
+		failOnError(cli.WriteFile(*out+".svg", func(f io.Writer) error { return chart.SVG(f) }), "Failed to write")
+		failOnError(cli.WriteFile(*out+".png", func(f io.Writer) error { return chart.PNG(f) }), "Failed to write")
+		return
+	}
+
+	for k, v := range variants {
+		h := &plot.Heatmap{
+			Title:  fmt.Sprintf("Control surface - %s", v.Name),
+			XLabel: inputs[0].Name,
+			YLabel: inputs[1].Name,
+			X:      xs,
+			Y:      ys,
+			Z:      z[k],
+		}
+		prefix := *out + "-" + v.Name
+		failOnError(cli.WriteFile(prefix+".svg", func(f io.Writer) error { return h.SVG(f) }), "Failed to write")
+		failOnError(cli.WriteFile(prefix+".png", func(f io.Writer) error { return h.PNG(f) }), "Failed to write")
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePrefetch(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want []int
+	}{
+		{"5", []int{5}},
+		{"1,2,10", []int{1, 2, 10}},
+		{"1-4", []int{1, 2, 3, 4}},
+		{"3-3", []int{3}},
+		{"10-30:10", []int{10, 20, 30}},
+		{"1-10:4", []int{1, 5, 9}},
+		{"1-3, 20-40:10", []int{1, 2, 3, 20, 30, 40}},
+		{"2-4,3,1-5:2", []int{2, 3, 4, 1, 5}},
+	} {
+		got, err := parsePrefetch(tc.in)
+		if err != nil {
+			t.Errorf("parsePrefetch(%q): %v", tc.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("parsePrefetch(%q) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestParsePrefetchErrors(t *testing.T) {
+	for _, tc := range []struct {
+		in, want string
+	}{
+		{"", `bad prefetch count ""`},
+		{"ten", `bad prefetch count "ten"`},
+		{"1,,2", `bad prefetch count ""`},
+		{"0", `bad prefetch range "0"`},
+		{"-5", `bad prefetch count "-5"`},
+		{"10-1", `bad prefetch range "10-1"`},
+		{"1-x", `bad prefetch range "1-x"`},
+		{"1-10:0", `bad prefetch range "1-10:0"`},
+		{"1-10:-2", `bad prefetch range "1-10:-2"`},
+		{"1-10:two", `bad prefetch range "1-10:two"`},
+		{"5:2", `bad prefetch range "5:2"`},
+	} {
+		_, err := parsePrefetch(tc.in)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("parsePrefetch(%q) = %v, want %q", tc.in, err, tc.want)
+		}
+	}
+}
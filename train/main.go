@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"math"
+	"math/rand"
+	"strings"
+
+	"rabbitMQ/anfis"
+	"rabbitMQ/controllers"
+	"rabbitMQ/fuzzy"
+	"rabbitMQ/plant"
+)
+
+func failOnError(err error, msg string) {
+	if err != nil {
+		log.Fatalf("%s: %s", msg, err)
+	}
+}
+
+// samples turns the measurements into training samples. Every ordered pair
+// of points is one: running at the prefetch count and rate of the first
+// with the rate of the second as goal, the controller should move the
+// prefetch count towards that of the second, by as many steps of step
+// messages as its output universe allows. The measurements are steady
+// states, so the change of error of -pd controllers is taken as zero.
+func samples(points []plant.Measurement, c *fuzzy.Controller, step float64) []anfis.Sample {
+	var ss []anfis.Sample
+	for i, from := range points {
+		for j, to := range points {
+			if i == j {
+				continue
+			}
+			u := (to.Prefetch - from.Prefetch) / step
+			ss = append(ss, anfis.Sample{
+				In: map[string]float64{
+					controllers.Error:      to.Rate - from.Rate,
+					controllers.DeltaError: 0,
+					controllers.Rate:       from.Rate,
+					controllers.Prefetch:   from.Prefetch,
+					controllers.Goal:       to.Rate,
+				},
+				Target: math.Max(c.Output.Min, math.Min(c.Output.Max, u)),
+			})
+		}
+	}
+	return ss
+}
+
+// train fits the controller of a consumer to prefetch count and rate
+// measurements with ANFIS and writes the result as a controller definition
+// the consumers load with -config. The built-in controller, as tuned by
+// the controller flags, gives the rule base and the initial membership
+// functions; every rule gets a linear consequent of -regressors.
+func main() {
+	variantName := flag.String("variant", "gaussian", "built-in controller to start from: gaussian, triangular or bell")
+	data := flag.String("data", "", ".xlsx or CSV file of prefetch (or PC) and rate measurements")
+	step := flag.Float64("prefetch-step", 2, "prefetch messages per unit of controller output, as in the consumer")
+	regressors := flag.String("regressors", "error,prefetch", "comma separated crisp inputs of the linear consequents")
+	epochs := flag.Int("epochs", 100, "least squares and gradient descent epochs")
+	rate := flag.Float64("learning-rate", 0.01, "initial gradient step, relative to the width of each input universe")
+	ridge := flag.Float64("ridge", 1e-6, "ridge regularization of the least squares fit")
+	maxSamples := flag.Int("max-samples", 2000, "train on a random subset of this many sample pairs (0 = all)")
+	seed := flag.Int64("seed", 1, "seed of the sample subset")
+	out := flag.String("o", "anfis.json", "output controller definition, .json or .yaml")
+	controllerFlags := controllers.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	if *data == "" {
+		log.Fatalf("-data is required")
+	}
+	variant, ok := controllers.Lookup(*variantName)
+	if !ok {
+		log.Fatalf("Unknown variant %q", *variantName)
+	}
+	c, err := controllerFlags.Controller(variant)
+	failOnError(err, "Failed to build the fuzzy controller")
+
+	points, err := plant.ReadMeasurements(*data)
+	failOnError(err, "Failed to read the measurements")
+
+	ss := samples(points, c, *step)
+	if *maxSamples > 0 && len(ss) > *maxSamples {
+		r := rand.New(rand.NewSource(*seed))
+		r.Shuffle(len(ss), func(i, j int) { ss[i], ss[j] = ss[j], ss[i] })
+		ss = ss[:*maxSamples]
+	}
+	log.Printf("Training on %d samples from %d measurements", len(ss), len(points))
+
+	res, err := anfis.Train(c, ss, anfis.Options{
+		Epochs:     *epochs,
+		Rate:       *rate,
+		Ridge:      *ridge,
+		Regressors: strings.Split(*regressors, ","),
+		Progress: func(epoch int, rmse float64) {
+			log.Printf("epoch %d: RMSE %.4f", epoch+1, rmse)
+		},
+	})
+	failOnError(err, "Failed to train the controller")
+	log.Printf("Best RMSE %.4f at epoch %d (initial %.4f)", res.RMSE[res.Best], res.Best+1, res.RMSE[0])
+
+	failOnError(fuzzy.SaveFile(*out, res.Controller), "Failed to write "+*out)
+	log.Printf("Wrote %s", *out)
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"rabbitMQ/controllers"
+	"rabbitMQ/fuzzy"
+	"rabbitMQ/optimize"
+	"rabbitMQ/plant"
+	"rabbitMQ/prefetch"
+)
+
+func failOnError(err error, msg string) {
+	if err != nil {
+		log.Fatalf("%s: %s", msg, err)
+	}
+}
+
+// paramNames names the parameters of every membership function type, in
+// the order of TermDef.Params.
+var paramNames = map[string][]string{
+	"triangular":  {"a", "b", "c"},
+	"trapezoidal": {"a", "b", "c", "d"},
+	"gaussian":    {"mu", "sigma"},
+	"bell":        {"a", "b", "c"},
+}
+
+// knob is one tuned parameter of a controller definition.
+type knob struct {
+	name     string
+	p        *float64
+	min, max float64
+}
+
+// knobs lists the parameters of d to tune: the rule weights and gains, the
+// input terms and, unless every rule is TSK, the output terms. Weights range
+// from 0.05 to 1, gains from 0.1 to 3, coordinates over the universe, widths
+// from a thousandth to half of it and bell slopes from 0.5 to 10; every
+// range is widened to take in the initial value.
+func knobs(d *fuzzy.Definition, tune map[string]bool) []knob {
+	var ks []knob
+	add := func(name string, p *float64, min, max float64) {
+		ks = append(ks, knob{name, p, math.Min(min, *p), math.Max(max, *p)})
+	}
+
+	if tune["weights"] {
+		for i := range d.Rules {
+			r := &d.Rules[i]
+			if r.Weight == nil {
+				r.Weight = new(float64)
+				*r.Weight = 1
+			}
+			add(fmt.Sprintf("rule %d weight", i+1), r.Weight, 0.05, 1)
+		}
+	}
+	if tune["gains"] {
+		for i := range d.Rules {
+			r := &d.Rules[i]
+			if r.Gain == nil {
+				r.Gain = new(float64)
+				*r.Gain = 1
+			}
+			add(fmt.Sprintf("rule %d gain", i+1), r.Gain, 0.1, 3)
+		}
+	}
+
+	vars := func(vds []fuzzy.VariableDef) {
+		for i := range vds {
+			vd := &vds[i]
+			lo, hi := vd.Range[0], vd.Range[1]
+			w := hi - lo
+			for j := range vd.Terms {
+				td := &vd.Terms[j]
+				prefix := vd.Name + "." + td.Name + "."
+				switch td.Type {
+				case "triangular", "trapezoidal":
+					for k := range td.Params {
+						add(prefix+paramNames[td.Type][k], &td.Params[k], lo, hi)
+					}
+				case "points":
+					for k := 0; k < len(td.Params); k += 2 {
+						add(fmt.Sprintf("%sx%d", prefix, k/2+1), &td.Params[k], lo, hi)
+					}
+				case "gaussian":
+					add(prefix+"mu", &td.Params[0], lo, hi)
+					add(prefix+"sigma", &td.Params[1], w/1000, w/2)
+				case "bell":
+					add(prefix+"a", &td.Params[0], w/1000, w/2)
+					add(prefix+"b", &td.Params[1], 0.5, 10)
+					add(prefix+"c", &td.Params[2], lo, hi)
+				}
+			}
+		}
+	}
+	if tune["inputs"] {
+		vars(d.Inputs)
+	}
+	if tune["output"] {
+		for _, r := range d.Rules {
+			if r.TSK == nil {
+				vars([]fuzzy.VariableDef{d.Output})
+				break
+			}
+		}
+	}
+	return ks
+}
+
+// decode sets the knobs to x and returns the controller d then describes.
+// Corners of triangles, trapezoids and point lists are sorted first, so
+// every x gives well-formed terms.
+func decode(d *fuzzy.Definition, ks []knob, x []float64) (*fuzzy.Controller, error) {
+	for i, k := range ks {
+		*k.p = x[i]
+	}
+	for _, vd := range append([]fuzzy.VariableDef{d.Output}, d.Inputs...) {
+		for _, td := range vd.Terms {
+			switch td.Type {
+			case "triangular", "trapezoidal":
+				sort.Float64s(td.Params)
+			case "points":
+				n := len(td.Params) / 2
+				sort.Sort(pointsByX(td.Params[:2*n]))
+			}
+		}
+	}
+	return d.Controller()
+}
+
+// pointsByX sorts the x y pairs of a point list by x.
+type pointsByX []float64
+
+func (p pointsByX) Len() int           { return len(p) / 2 }
+func (p pointsByX) Less(i, j int) bool { return p[2*i] < p[2*j] }
+func (p pointsByX) Swap(i, j int) {
+	p[2*i], p[2*j] = p[2*j], p[2*i]
+	p[2*i+1], p[2*j+1] = p[2*j+1], p[2*i+1]
+}
+
+// scenario is a closed loop run with a step of the goal.
+type scenario struct {
+	model                  plant.Model
+	from, to               float64
+	at, windows            int
+	min, max               int
+	step, band             float64
+	wSettle, wOver, wError float64
+}
+
+// cost runs the step of s with the controller d describes and weighs its
+// settling time, as a fraction of the time after the step, its overshoot
+// and its steady-state error.
+func (s scenario) cost(d func() (*fuzzy.Controller, error)) (float64, plant.StepResponse) {
+	c, err := d()
+	if err != nil {
+		return math.Inf(1), plant.StepResponse{}
+	}
+	initial := int(math.Round(s.model.Prefetch(s.from)))
+	a, err := prefetch.NewAdjuster(initial, s.min, s.max, s.step)
+	if err != nil {
+		return math.Inf(1), plant.StepResponse{}
+	}
+	r, err := s.model.Run(c, a, plant.Step(s.from, s.to, s.at, s.windows), 0)
+	if err != nil {
+		return math.Inf(1), plant.StepResponse{}
+	}
+	sr := r.Step(s.at, s.band)
+	settle := sr.SettlingTime.Seconds() / (r.Window.Seconds() * float64(s.windows-s.at))
+	return s.wSettle*settle + s.wOver*sr.Overshoot + s.wError*sr.SteadyStateError, sr
+}
+
+// tune optimizes the rule weights and gains and the membership function
+// parameters of the controller of a consumer, with a genetic algorithm or a
+// particle swarm, against steps of the goal up and down on a plant model. It
+// writes the best controller as a definition the consumers load with
+// -config, prints the tuned parameters and logs the best and mean cost of
+// every generation.
+func main() {
+	variantName := flag.String("variant", "gaussian", "built-in controller to tune: gaussian, triangular or bell")
+	algorithm := flag.String("algorithm", "ga", "optimizer: ga (genetic algorithm) or pso (particle swarm)")
+	population := flag.Int("population", 30, "parameter sets per generation, or particles of the swarm")
+	generations := flag.Int("generations", 50, "generations, or iterations of the swarm")
+	seed := flag.Int64("seed", 1, "random seed")
+	tuneList := flag.String("tune", "weights,gains,inputs,output", "comma separated parameters to tune: rule weights and gains, inputs and output terms")
+	plantPath := flag.String("plant", "", "plant model JSON (default the fit of the first experiment)")
+	from := flag.Float64("from", 5000, "goal before the step, in msg/sec")
+	to := flag.Float64("to", 12000, "goal after the step, in msg/sec; the step is also run back down")
+	windows := flag.Int("windows", 60, "control windows of every run")
+	band := flag.Float64("band", 0.1, "settling band, as a fraction of the step")
+	minPrefetch := flag.Int("prefetch-min", 1, "lowest prefetch count the controller may set")
+	maxPrefetch := flag.Int("prefetch-max", 100, "highest prefetch count the controller may set")
+	step := flag.Float64("prefetch-step", 2, "prefetch messages per unit of controller output")
+	wSettle := flag.Float64("w-settling", 1, "cost weight of the settling time")
+	wOver := flag.Float64("w-overshoot", 1, "cost weight of the overshoot")
+	wError := flag.Float64("w-error", 1, "cost weight of the steady-state error")
+	out := flag.String("o", "tuned.json", "output controller definition, .json, .yaml or .fcl")
+	logPath := flag.String("log", "convergence.csv", "convergence log CSV")
+	controllerFlags := controllers.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	variant, ok := controllers.Lookup(*variantName)
+	if !ok {
+		log.Fatalf("Unknown variant %q", *variantName)
+	}
+	c, err := controllerFlags.Controller(variant)
+	failOnError(err, "Failed to build the fuzzy controller")
+	model := plant.FirstExperiment
+	if *plantPath != "" {
+		model, err = plant.Load(*plantPath)
+		failOnError(err, "Failed to load the plant model")
+	}
+	if *to >= model.RMax || *from >= model.RMax {
+		log.Fatalf("Goals must be below the saturation rate of the plant, %.0f msg/sec", model.RMax)
+	}
+	_, err = prefetch.NewAdjuster(*minPrefetch, *minPrefetch, *maxPrefetch, *step)
+	failOnError(err, "Invalid prefetch bounds")
+	if *windows < 10 {
+		log.Fatalf("-windows must be at least 10")
+	}
+
+	tune := map[string]bool{}
+	for _, name := range strings.Split(*tuneList, ",") {
+		if name != "weights" && name != "gains" && name != "inputs" && name != "output" {
+			log.Fatalf("Unknown parameters %q to tune", name)
+		}
+		tune[name] = true
+	}
+
+	d, err := fuzzy.NewDefinition(c)
+	failOnError(err, "Failed to describe the fuzzy controller")
+	ks := knobs(d, tune)
+	if len(ks) == 0 {
+		log.Fatalf("Nothing to tune")
+	}
+	x0 := make([]float64, len(ks))
+	p := optimize.Problem{Min: make([]float64, len(ks)), Max: make([]float64, len(ks))}
+	for i, k := range ks {
+		x0[i], p.Min[i], p.Max[i] = *k.p, k.min, k.max
+	}
+
+	up := scenario{
+		model: model, from: *from, to: *to, at: 5, windows: *windows,
+		min: *minPrefetch, max: *maxPrefetch, step: *step, band: *band,
+		wSettle: *wSettle, wOver: *wOver, wError: *wError,
+	}
+	down := up
+	down.from, down.to = *to, *from
+	evaluate := func(x []float64) (float64, []plant.StepResponse) {
+		total := 0.0
+		var srs []plant.StepResponse
+		for _, s := range []scenario{up, down} {
+			cost, sr := s.cost(func() (*fuzzy.Controller, error) { return decode(d, ks, x) })
+			total += cost
+			srs = append(srs, sr)
+		}
+		return total, srs
+	}
+	p.Cost = func(x []float64) float64 {
+		cost, _ := evaluate(x)
+		return cost
+	}
+
+	report := func(label string, x []float64) {
+		cost, srs := evaluate(x)
+		log.Printf("%s cost %.4f", label, cost)
+		for i, sr := range srs {
+			log.Printf("  step %d: settling %v (settled %v), overshoot %.1f%%, steady-state error %.2f%%",
+				i+1, sr.SettlingTime, sr.Settled, 100*sr.Overshoot, 100*sr.SteadyStateError)
+		}
+	}
+	report("Initial", x0)
+
+	progress := func(pr optimize.Progress) {
+		log.Printf("iteration %d: best %.4f, mean %.4f", pr.Iteration, pr.Best, pr.Mean)
+	}
+	r := rand.New(rand.NewSource(*seed))
+	var res *optimize.Result
+	switch *algorithm {
+	case "ga":
+		res, err = optimize.GA(p, x0, optimize.GAOptions{Population: *population, Generations: *generations, Rand: r, Progress: progress})
+	case "pso":
+		res, err = optimize.PSO(p, x0, optimize.PSOOptions{Particles: *population, Iterations: *generations, Rand: r, Progress: progress})
+	default:
+		log.Fatalf("Unknown algorithm %q", *algorithm)
+	}
+	failOnError(err, "Failed to tune the controller")
+	report("Best", res.Best)
+
+	f, err := os.Create(*logPath)
+	failOnError(err, "Failed to create "+*logPath)
+	w := csv.NewWriter(f)
+	w.Write([]string{"iteration", "best", "mean"})
+	for _, pr := range res.History {
+		w.Write([]string{strconv.Itoa(pr.Iteration), strconv.FormatFloat(pr.Best, 'g', -1, 64), strconv.FormatFloat(pr.Mean, 'g', -1, 64)})
+	}
+	w.Flush()
+	failOnError(w.Error(), "Failed to write "+*logPath)
+	failOnError(f.Close(), "Failed to write "+*logPath)
+	log.Printf("Wrote %s", *logPath)
+
+	best, err := decode(d, ks, res.Best)
+	failOnError(err, "Failed to build the tuned controller")
+	for i, k := range ks {
+		fmt.Printf("%s\t%g\t(was %g)\n", k.name, *k.p, x0[i])
+	}
+	failOnError(fuzzy.SaveFile(*out, best), "Failed to write "+*out)
+	log.Printf("Wrote %s", *out)
+}